@@ -0,0 +1,257 @@
+package websitecategorization
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+)
+
+// BlocklistFormat is the file format written by Blocklist.
+type BlocklistFormat string
+
+// Blocklist formats.
+const (
+	// BlocklistHosts is the hosts file format: "0.0.0.0 example.com". Hosts files match exact names only,
+	// so subdomains of blocked domains are kept and allowlisted subdomains are just left out.
+	BlocklistHosts BlocklistFormat = "hosts"
+
+	// BlocklistDnsmasq is the dnsmasq configuration: "address=/example.com/#" answering with null addresses,
+	// allowlisted subdomains are forwarded to the upstream servers with "server=/ok.example.com/#".
+	BlocklistDnsmasq BlocklistFormat = "dnsmasq"
+
+	// BlocklistUnbound is the Unbound configuration included in the server clause:
+	// local-zone: "example.com." always_nxdomain, allowlisted subdomains are transparent zones.
+	BlocklistUnbound BlocklistFormat = "unbound"
+
+	// BlocklistRPZ is the BIND Response Policy Zone file answering NXDOMAIN for the blocked domains
+	// and their subdomains, allowlisted subdomains are passed through.
+	BlocklistRPZ BlocklistFormat = "rpz"
+
+	// BlocklistAdblock is the Adblock-style filter list: "||example.com^", allowlisted subdomains are
+	// the exception rules "@@||ok.example.com^".
+	BlocklistAdblock BlocklistFormat = "adblock"
+)
+
+// BlocklistFormats is the list of supported formats.
+var BlocklistFormats = []BlocklistFormat{
+	BlocklistHosts, BlocklistDnsmasq, BlocklistUnbound, BlocklistRPZ, BlocklistAdblock,
+}
+
+// ErrUnknownBlocklistFormat is returned when the blocklist format is not supported.
+var ErrUnknownBlocklistFormat = errors.New("unknown blocklist format")
+
+// Blocklist collects the domains blocked by the policy and writes them in the formats of DNS servers
+// and content filters. The output is sorted, so blocklists generated from the same results are identical.
+// The zero value blocks nothing until domains are added with AddDomain. It is not safe for concurrent use.
+type Blocklist struct {
+	// Policy selects the blocked categories.
+	Policy Policy
+
+	// Allowlist are the domains that are never blocked. A domain matches itself and its subdomains.
+	Allowlist []string
+
+	// SinkholeAddress is the address of the blocked names in hosts files. Default: 0.0.0.0.
+	SinkholeAddress string
+
+	// RPZSerial is the serial number of the RPZ zone. Default: 1.
+	RPZSerial uint32
+
+	domains map[string]bool
+}
+
+// Add adds the domain of the result if the policy blocks it. It reports whether the domain is added.
+func (b *Blocklist) Add(resp *WCategorizationResponse) bool {
+	if resp == nil || !b.Policy.Decide(resp).Blocked() {
+		return false
+	}
+
+	return b.AddDomain(resp.DomainName)
+}
+
+// AddDomain adds the domain regardless of the policy. IP addresses and empty names are ignored.
+// It reports whether the domain is added.
+func (b *Blocklist) AddDomain(domainName string) bool {
+	name := normalizeBlocklistName(domainName)
+	if name == "" || net.ParseIP(name) != nil {
+		return false
+	}
+
+	if b.domains == nil {
+		b.domains = make(map[string]bool)
+	}
+
+	b.domains[name] = true
+
+	return true
+}
+
+// AddFrom adds the domains of the results read from the reader that the policy blocks.
+// It returns the number of added domains.
+func (b *Blocklist) AddFrom(r ResponseReader) (int, error) {
+	var n int
+
+	for {
+		resp, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return n, nil
+		}
+
+		if err != nil {
+			return n, err
+		}
+
+		if b.Add(resp) {
+			n++
+		}
+	}
+}
+
+// Len returns the number of added domains.
+func (b *Blocklist) Len() int {
+	return len(b.domains)
+}
+
+// normalizeBlocklistName returns the lowercase name without the trailing dot.
+func normalizeBlocklistName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+}
+
+// underAny reports whether the name or one of its parents is in the set.
+func underAny(name string, set map[string]bool) bool {
+	for {
+		if set[name] {
+			return true
+		}
+
+		dot := strings.IndexByte(name, '.')
+		if dot < 0 {
+			return false
+		}
+
+		name = name[dot+1:]
+	}
+}
+
+// parentIn reports whether a parent of the name is in the set.
+func parentIn(name string, set map[string]bool) bool {
+	dot := strings.IndexByte(name, '.')
+
+	return dot >= 0 && underAny(name[dot+1:], set)
+}
+
+// Entries returns the sorted blocked names and the allowlisted exceptions for the format. Allowlisted domains
+// and their subdomains are removed. Formats blocking subdomains get no names under other blocked names
+// and the exceptions for the allowlisted subdomains of blocked names.
+func (b *Blocklist) Entries(format BlocklistFormat) (blocked, exceptions []string) {
+	allowed := make(map[string]bool, len(b.Allowlist))
+	for _, name := range b.Allowlist {
+		if name = normalizeBlocklistName(name); name != "" {
+			allowed[name] = true
+		}
+	}
+
+	subdomains := format != BlocklistHosts
+
+	for name := range b.domains {
+		if underAny(name, allowed) || subdomains && parentIn(name, b.domains) {
+			continue
+		}
+
+		blocked = append(blocked, name)
+	}
+
+	if subdomains {
+		for name := range allowed {
+			// Exceptions under other exceptions are redundant.
+			if parentIn(name, b.domains) && !parentIn(name, allowed) {
+				exceptions = append(exceptions, name)
+			}
+		}
+	}
+
+	sort.Strings(blocked)
+	sort.Strings(exceptions)
+
+	return blocked, exceptions
+}
+
+// Write writes the blocklist in the format.
+func (b *Blocklist) Write(w io.Writer, format BlocklistFormat) error {
+	bw := bufio.NewWriter(w)
+
+	blocked, exceptions := b.Entries(format)
+
+	switch format {
+	case BlocklistHosts:
+		address := b.SinkholeAddress
+		if address == "" {
+			address = "0.0.0.0"
+		}
+
+		for _, name := range blocked {
+			fmt.Fprintf(bw, "%s %s\n", address, name)
+		}
+	case BlocklistDnsmasq:
+		for _, name := range blocked {
+			fmt.Fprintf(bw, "address=/%s/#\n", name)
+		}
+
+		for _, name := range exceptions {
+			fmt.Fprintf(bw, "server=/%s/#\n", name)
+		}
+	case BlocklistUnbound:
+		for _, name := range blocked {
+			fmt.Fprintf(bw, "local-zone: %q always_nxdomain\n", name+".")
+		}
+
+		for _, name := range exceptions {
+			fmt.Fprintf(bw, "local-zone: %q transparent\n", name+".")
+		}
+	case BlocklistRPZ:
+		serial := b.RPZSerial
+		if serial == 0 {
+			serial = 1
+		}
+
+		fmt.Fprintf(bw, "$TTL 300\n@ IN SOA localhost. root.localhost. %d 3600 600 86400 300\n@ IN NS localhost.\n", serial)
+
+		for _, name := range blocked {
+			fmt.Fprintf(bw, "%s CNAME .\n*.%s CNAME .\n", name, name)
+		}
+
+		for _, name := range exceptions {
+			fmt.Fprintf(bw, "%s CNAME rpz-passthru.\n*.%s CNAME rpz-passthru.\n", name, name)
+		}
+	case BlocklistAdblock:
+		bw.WriteString("[Adblock Plus 2.0]\n")
+
+		for _, name := range blocked {
+			fmt.Fprintf(bw, "||%s^\n", name)
+		}
+
+		for _, name := range exceptions {
+			fmt.Fprintf(bw, "@@||%s^\n", name)
+		}
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownBlocklistFormat, format)
+	}
+
+	return bw.Flush()
+}
+
+// WriteFile writes the blocklist in the format to the file. The file is replaced atomically, so DNS servers
+// and filters reloading it never read a partially written blocklist, and it is left intact if writing fails.
+func (b *Blocklist) WriteFile(path string, format BlocklistFormat) error {
+	var buf bytes.Buffer
+
+	if err := b.Write(&buf, format); err != nil {
+		return err
+	}
+
+	return writeFileAtomic(path, buf.Bytes(), 0o644)
+}
@@ -0,0 +1,132 @@
+package websitecategorization
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestBlocklist tests the blocklists written in every format.
+func TestBlocklist(t *testing.T) {
+	input := `{"domainName":"casino.com","categories":[{"id":1,"confidence":0.9}]}
+{"domainName":"www.casino.com","categories":[{"id":1,"confidence":0.9}]}
+{"domainName":"Bet.Example.","categories":[{"id":1,"confidence":0.8}]}
+{"domainName":"unsure.com","categories":[{"id":1,"confidence":0.3}]}
+{"domainName":"shop.com","categories":[{"id":3,"confidence":0.9}]}
+{"domainName":"partner.org","categories":[{"id":1,"confidence":0.9}]}
+{"domainName":"1.2.3.4","categories":[{"id":1,"confidence":0.9}]}
+`
+
+	blocklist := &Blocklist{
+		Policy:    Policy{Deny: []int{1}, MinConfidence: 0.5},
+		Allowlist: []string{"partner.org", "help.casino.com", "docs.help.casino.com"},
+		RPZSerial: 2021010101,
+	}
+
+	n, err := blocklist.AddFrom(NewJSONLReader(strings.NewReader(input)))
+	if err != nil || n != 4 {
+		t.Fatalf("AddFrom() = %d, %v, want 4", n, err)
+	}
+
+	tests := []struct {
+		format BlocklistFormat
+		want   string
+	}{
+		{
+			format: BlocklistHosts,
+			want: `0.0.0.0 bet.example
+0.0.0.0 casino.com
+0.0.0.0 www.casino.com
+`,
+		},
+		{
+			format: BlocklistDnsmasq,
+			want: `address=/bet.example/#
+address=/casino.com/#
+server=/help.casino.com/#
+`,
+		},
+		{
+			format: BlocklistUnbound,
+			want: `local-zone: "bet.example." always_nxdomain
+local-zone: "casino.com." always_nxdomain
+local-zone: "help.casino.com." transparent
+`,
+		},
+		{
+			format: BlocklistRPZ,
+			want: `$TTL 300
+@ IN SOA localhost. root.localhost. 2021010101 3600 600 86400 300
+@ IN NS localhost.
+bet.example CNAME .
+*.bet.example CNAME .
+casino.com CNAME .
+*.casino.com CNAME .
+help.casino.com CNAME rpz-passthru.
+*.help.casino.com CNAME rpz-passthru.
+`,
+		},
+		{
+			format: BlocklistAdblock,
+			want: `[Adblock Plus 2.0]
+||bet.example^
+||casino.com^
+@@||help.casino.com^
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := blocklist.Write(&buf, tt.format); err != nil {
+				t.Fatal(err)
+			}
+
+			if buf.String() != tt.want {
+				t.Errorf("Write() =\n%s\nwant\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+
+	if err = blocklist.Write(&bytes.Buffer{}, "squid"); !errors.Is(err, ErrUnknownBlocklistFormat) {
+		t.Errorf("Write() error = %v, want ErrUnknownBlocklistFormat", err)
+	}
+}
+
+// TestBlocklistWriteFile tests that the blocklist file is replaced only when the blocklist is written completely.
+func TestBlocklistWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocked.hosts")
+
+	blocklist := &Blocklist{}
+	blocklist.AddDomain("casino.com")
+
+	if err := blocklist.WriteFile(path, BlocklistHosts); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := blocklist.WriteFile(path, "squid"); !errors.Is(err, ErrUnknownBlocklistFormat) {
+		t.Errorf("WriteFile() error = %v, want ErrUnknownBlocklistFormat", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "0.0.0.0 casino.com\n" {
+		t.Errorf("blocklist file = %q, %v", data, err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0o644 {
+		t.Errorf("blocklist file mode = %v, want it readable by DNS servers", info.Mode())
+	}
+
+	if matches, _ := filepath.Glob(path + ".*.tmp"); len(matches) > 0 {
+		t.Errorf("temporary files are left: %v", matches)
+	}
+}
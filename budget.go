@@ -0,0 +1,402 @@
+package websitecategorization
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExceeded is matched by errors.Is for BudgetExceededError.
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// BudgetScope is the scope of a budget limit.
+type BudgetScope string
+
+// Budget scopes.
+const (
+	BudgetScopeDaily    BudgetScope = "daily"
+	BudgetScopeTotal    BudgetScope = "total"
+	BudgetScopeJob      BudgetScope = "job"
+	BudgetScopeKeyDaily BudgetScope = "key daily"
+)
+
+const (
+	// budgetDayLayout is the format of BudgetUsage.Day.
+	budgetDayLayout = "2006-01-02"
+
+	// keyFingerprintLen is the number of hash bytes in the API key fingerprint.
+	keyFingerprintLen = 8
+)
+
+// BudgetExceededError is returned instead of making a billable request when the budget is spent.
+type BudgetExceededError struct {
+	// Scope is the scope of the exceeded limit.
+	Scope BudgetScope
+
+	// Limit is the configured number of billable requests.
+	Limit int
+
+	// Job is the job name for BudgetScopeJob.
+	Job string
+}
+
+// Error returns error message as a string.
+func (e *BudgetExceededError) Error() string {
+	if e.Job != "" {
+		return fmt.Sprintf("budget exceeded: %s limit of %d requests reached for job %q", e.Scope, e.Limit, e.Job)
+	}
+
+	return fmt.Sprintf("budget exceeded: %s limit of %d requests reached", e.Scope, e.Limit)
+}
+
+// Is reports whether the target is ErrBudgetExceeded.
+func (e *BudgetExceededError) Is(target error) bool {
+	return target == ErrBudgetExceeded
+}
+
+// BudgetLimits is the set of limits on billable requests. Zero value of a limit means no limit.
+type BudgetLimits struct {
+	// Daily is the maximum number of billable requests per calendar day in UTC.
+	Daily int `json:"daily,omitempty"`
+
+	// Total is the maximum number of billable requests over the lifetime of the counters.
+	Total int `json:"total,omitempty"`
+
+	// PerJob is the maximum number of billable requests made with the same job name, see WithBudgetJob.
+	PerJob int `json:"perJob,omitempty"`
+
+	// PerKeyDaily is the maximum number of billable requests per API key per calendar day in UTC.
+	PerKeyDaily int `json:"perKeyDaily,omitempty"`
+}
+
+// BudgetUsage is the snapshot of the budget counters.
+type BudgetUsage struct {
+	// Day is the current calendar day in the YYYY-MM-DD format.
+	Day string `json:"day"`
+
+	// Daily is the number of billable requests made during the day.
+	Daily int `json:"daily"`
+
+	// Total is the number of billable requests made over the lifetime of the counters.
+	Total int `json:"total"`
+
+	// Jobs is the number of billable requests per job name.
+	Jobs map[string]int `json:"jobs,omitempty"`
+
+	// Keys is the number of billable requests made during the day per API key fingerprint.
+	// API keys themselves are never stored.
+	Keys map[string]int `json:"keys,omitempty"`
+}
+
+// Budget counts billable requests and refuses further requests once a limit is reached.
+// Only domain lookups that are actually sent to the API are counted: cache hits, invalid arguments
+// and requests that failed before reaching the API do not count. It is safe for concurrent use.
+type Budget struct {
+	mu sync.Mutex
+
+	limits    BudgetLimits
+	statePath string
+	usage     BudgetUsage
+
+	thresholds  []float64
+	onThreshold func(BudgetThreshold)
+
+	// reported are the reported thresholds, so released and reserved again requests do not repeat them.
+	reported map[string]bool
+
+	// version is incremented by every change of the counters.
+	version int64
+
+	// saveMu serializes the writes of the state file, savedVersion is the version of the saved counters.
+	// The file is written without holding mu, so requests do not wait for the disk to check the limits.
+	saveMu       sync.Mutex
+	savedVersion int64
+
+	now func() time.Time
+}
+
+// BudgetThreshold is reported when the usage of the daily or the total limit reaches a fraction of the limit.
+type BudgetThreshold struct {
+	// Scope is BudgetScopeDaily or BudgetScopeTotal.
+	Scope BudgetScope `json:"scope"`
+
+	// Fraction is the reached fraction of the limit, e.g. 0.8.
+	Fraction float64 `json:"fraction"`
+
+	// Used is the number of counted requests.
+	Used int `json:"used"`
+
+	// Limit is the limit value.
+	Limit int `json:"limit"`
+}
+
+// NewBudget creates Budget with the specified limits. If statePath is not empty the counters are
+// loaded from the file and saved to it after every change before the request is sent.
+func NewBudget(limits BudgetLimits, statePath string) (*Budget, error) {
+	b := &Budget{
+		limits:    limits,
+		statePath: statePath,
+		now:       time.Now,
+	}
+
+	if statePath == "" {
+		return b, nil
+	}
+
+	data, err := os.ReadFile(statePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return b, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, &b.usage); err != nil {
+		return nil, fmt.Errorf("cannot parse budget state: %w", err)
+	}
+
+	return b, nil
+}
+
+// Usage returns the current counters.
+func (b *Budget) Usage() BudgetUsage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.rotate()
+
+	usage := b.usage
+	usage.Jobs = copyCounters(b.usage.Jobs)
+	usage.Keys = copyCounters(b.usage.Keys)
+
+	return usage
+}
+
+// OnThreshold sets the function called when the usage of the daily or the total limit reaches one of
+// the fractions of the limit, e.g. 0.8 and 1. The daily thresholds are reported again every day.
+// The function is called synchronously by the request that reaches the threshold.
+func (b *Budget) OnThreshold(fractions []float64, f func(BudgetThreshold)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.thresholds = append([]float64(nil), fractions...)
+	b.onThreshold = f
+}
+
+// reached returns the thresholds reached exactly by the counted usage.
+func (b *Budget) reached(counted BudgetUsage) []BudgetThreshold {
+	var reached []BudgetThreshold
+
+	for _, fraction := range b.thresholds {
+		for _, limit := range []struct {
+			scope BudgetScope
+			limit int
+			used  int
+		}{
+			{BudgetScopeDaily, b.limits.Daily, counted.Daily},
+			{BudgetScopeTotal, b.limits.Total, counted.Total},
+		} {
+			if limit.limit <= 0 {
+				continue
+			}
+
+			threshold := int(math.Ceil(fraction * float64(limit.limit)))
+			if threshold < 1 {
+				threshold = 1
+			}
+
+			period := ""
+			if limit.scope == BudgetScopeDaily {
+				period = counted.Day
+			}
+
+			reportKey := fmt.Sprintf("%s %g %s", limit.scope, fraction, period)
+
+			if limit.used == threshold && !b.reported[reportKey] {
+				if b.reported == nil {
+					b.reported = make(map[string]bool)
+				}
+
+				b.reported[reportKey] = true
+
+				reached = append(reached, BudgetThreshold{
+					Scope:    limit.scope,
+					Fraction: fraction,
+					Used:     limit.used,
+					Limit:    limit.limit,
+				})
+			}
+		}
+	}
+
+	return reached
+}
+
+// budgetJobKey is the context key of the job name.
+type budgetJobKey struct{}
+
+// WithBudgetJob returns the context that makes requests count against the per-job limit of the job.
+func WithBudgetJob(ctx context.Context, job string) context.Context {
+	return context.WithValue(ctx, budgetJobKey{}, job)
+}
+
+// reserve counts the request made with the API key or returns BudgetExceededError.
+func (b *Budget) reserve(ctx context.Context, apiKey string) error {
+	job, _ := ctx.Value(budgetJobKey{}).(string)
+	key := keyFingerprint(apiKey)
+
+	counted, version, err := b.count(job, key)
+	if err != nil {
+		return err
+	}
+
+	if err = b.save(version); err != nil {
+		b.mu.Lock()
+		b.add(job, key, -1)
+		b.mu.Unlock()
+
+		return err
+	}
+
+	b.mu.Lock()
+	reached, onThreshold := b.reached(counted), b.onThreshold
+	b.mu.Unlock()
+
+	// The callback is called without the lock, so it can use the budget.
+	for _, threshold := range reached {
+		onThreshold(threshold)
+	}
+
+	return nil
+}
+
+// count counts the request made with the API key for the job. It returns the counters including
+// the request and their version.
+func (b *Budget) count(job, key string) (BudgetUsage, int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.rotate()
+
+	switch {
+	case b.limits.Daily > 0 && b.usage.Daily >= b.limits.Daily:
+		return BudgetUsage{}, 0, &BudgetExceededError{Scope: BudgetScopeDaily, Limit: b.limits.Daily}
+	case b.limits.Total > 0 && b.usage.Total >= b.limits.Total:
+		return BudgetUsage{}, 0, &BudgetExceededError{Scope: BudgetScopeTotal, Limit: b.limits.Total}
+	case b.limits.PerJob > 0 && job != "" && b.usage.Jobs[job] >= b.limits.PerJob:
+		return BudgetUsage{}, 0, &BudgetExceededError{Scope: BudgetScopeJob, Limit: b.limits.PerJob, Job: job}
+	case b.limits.PerKeyDaily > 0 && b.usage.Keys[key] >= b.limits.PerKeyDaily:
+		return BudgetUsage{}, 0, &BudgetExceededError{Scope: BudgetScopeKeyDaily, Limit: b.limits.PerKeyDaily}
+	}
+
+	b.add(job, key, 1)
+
+	return BudgetUsage{Day: b.usage.Day, Daily: b.usage.Daily, Total: b.usage.Total}, b.version, nil
+}
+
+// release takes back the request reserved with the API key that has not reached the API.
+func (b *Budget) release(ctx context.Context, apiKey string) {
+	job, _ := ctx.Value(budgetJobKey{}).(string)
+
+	b.mu.Lock()
+	b.add(job, keyFingerprint(apiKey), -1)
+	version := b.version
+	b.mu.Unlock()
+
+	// The request has failed already, the state is saved again on the next reservation.
+	_ = b.save(version)
+}
+
+// add changes the counters by delta.
+func (b *Budget) add(job, key string, delta int) {
+	b.version++
+	b.usage.Daily += delta
+	b.usage.Total += delta
+
+	if job != "" {
+		if b.usage.Jobs == nil {
+			b.usage.Jobs = make(map[string]int)
+		}
+
+		b.usage.Jobs[job] += delta
+	}
+
+	if b.usage.Keys == nil {
+		b.usage.Keys = make(map[string]int)
+	}
+
+	b.usage.Keys[key] += delta
+}
+
+// rotate resets the daily counters when the day changes.
+func (b *Budget) rotate() {
+	day := b.now().UTC().Format(budgetDayLayout)
+	if b.usage.Day == day {
+		return
+	}
+
+	b.usage.Day = day
+	b.usage.Daily = 0
+	b.usage.Keys = nil
+}
+
+// save writes the counters to the state file if it is set and the version of the counters is not saved yet.
+// Concurrent requests waiting for the write are saved together by the first of them.
+func (b *Budget) save(version int64) error {
+	if b.statePath == "" {
+		return nil
+	}
+
+	b.saveMu.Lock()
+	defer b.saveMu.Unlock()
+
+	if b.savedVersion >= version {
+		return nil
+	}
+
+	b.mu.Lock()
+	data, err := json.Marshal(b.usage)
+	current := b.version
+	b.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	if err = writeFileAtomic(b.statePath, data, 0o600); err != nil {
+		return fmt.Errorf("cannot save budget state: %w", err)
+	}
+
+	b.savedVersion = current
+
+	return nil
+}
+
+// keyFingerprint returns the short hash of the API key that is safe to store and log.
+func keyFingerprint(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+
+	return hex.EncodeToString(sum[:keyFingerprintLen])
+}
+
+// copyCounters returns a copy of the map.
+func copyCounters(counters map[string]int) map[string]int {
+	if counters == nil {
+		return nil
+	}
+
+	c := make(map[string]int, len(counters))
+	for k, v := range counters {
+		c[k] = v
+	}
+
+	return c
+}
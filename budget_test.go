@@ -0,0 +1,202 @@
+package websitecategorization
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestBudget tests that the Client stops making requests when the Budget is spent.
+func TestBudget(t *testing.T) {
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_, _ = w.Write([]byte(`{"domainName":"` + req.URL.Query().Get("domainName") + `","categories":[]}`))
+	}))
+	defer server.Close()
+
+	apiURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statePath := filepath.Join(t.TempDir(), "budget.json")
+
+	budget, err := NewBudget(BudgetLimits{Daily: 3, PerJob: 1}, statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2021, 1, 1, 23, 0, 0, 0, time.UTC)
+	budget.now = func() time.Time { return now }
+
+	client := NewClient(apiKey, ClientParams{
+		HTTPClient:             server.Client(),
+		WCategorizationBaseURL: apiURL,
+		Cache:                  NewMemoryCache(0, 0),
+		Budget:                 budget,
+	})
+
+	ctx := context.Background()
+	jobCtx := WithBudgetJob(ctx, "refresh")
+
+	calls := []struct {
+		ctx        context.Context
+		domainName string
+		wantErr    error
+	}{
+		{ctx, "a.com", nil},
+		{ctx, "a.com", nil},
+		{ctx, "", nil},
+		{jobCtx, "b.com", nil},
+		{jobCtx, "c.com", ErrBudgetExceeded},
+		{ctx, "c.com", nil},
+		{ctx, "d.com", ErrBudgetExceeded},
+	}
+
+	for _, call := range calls {
+		_, _, err = client.Get(call.ctx, call.domainName)
+
+		var argErr *ArgError
+		if errors.As(err, &argErr) {
+			continue
+		}
+
+		if !errors.Is(err, call.wantErr) {
+			t.Errorf("Client.Get(%q) error = %v, want %v", call.domainName, err, call.wantErr)
+		}
+	}
+
+	if got := atomic.LoadInt32(&requests); got != 3 {
+		t.Errorf("API requests = %d, want 3", got)
+	}
+
+	var budgetErr *BudgetExceededError
+	if _, _, err = client.Get(ctx, "e.com"); !errors.As(err, &budgetErr) || budgetErr.Scope != BudgetScopeDaily {
+		t.Errorf("Client.Get() error = %v, want daily budget error", err)
+	}
+
+	restored, err := NewBudget(BudgetLimits{}, statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored.now = budget.now
+
+	usage := restored.Usage()
+	if usage.Daily != 3 || usage.Total != 3 || usage.Jobs["refresh"] != 1 || usage.Keys[keyFingerprint(apiKey)] != 3 {
+		t.Errorf("restored Budget.Usage() = %+v", usage)
+	}
+
+	now = now.Add(time.Hour)
+
+	if usage = budget.Usage(); usage.Daily != 0 || usage.Total != 3 || usage.Day != "2021-01-02" {
+		t.Errorf("Budget.Usage() on the next day = %+v", usage)
+	}
+
+	server.Close()
+
+	if _, _, err = client.Get(ctx, "f.com"); err == nil || errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Client.Get() error = %v, want request error", err)
+	}
+
+	if usage = budget.Usage(); usage.Daily != 0 {
+		t.Errorf("failed requests must not count, Budget.Usage() = %+v", usage)
+	}
+}
+
+// TestBudgetConcurrentSave tests that the state saved by concurrent requests contains all of them.
+func TestBudgetConcurrentSave(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "budget.json")
+
+	budget, err := NewBudget(BudgetLimits{Total: 100}, statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if err := budget.reserve(context.Background(), apiKey); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	restored, err := NewBudget(BudgetLimits{}, statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if usage := restored.Usage(); usage.Total != 20 {
+		t.Errorf("restored Budget.Usage() = %+v, want 20 requests", usage)
+	}
+}
+
+// TestBudgetOnThreshold tests the reports of reached budget thresholds.
+func TestBudgetOnThreshold(t *testing.T) {
+	budget, err := NewBudget(BudgetLimits{Daily: 4, Total: 10}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+	budget.now = func() time.Time { return now }
+
+	var reached []BudgetThreshold
+
+	budget.OnThreshold([]float64{0.5, 1}, func(threshold BudgetThreshold) {
+		// The callback can use the budget.
+		_ = budget.Usage()
+
+		reached = append(reached, threshold)
+	})
+
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err = budget.reserve(ctx, apiKey); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The released and reserved again request does not repeat the report.
+	budget.release(ctx, apiKey)
+
+	for i := 0; i < 3; i++ {
+		_ = budget.reserve(ctx, apiKey)
+	}
+
+	now = now.Add(24 * time.Hour)
+
+	for i := 0; i < 2; i++ {
+		_ = budget.reserve(ctx, apiKey)
+	}
+
+	want := []BudgetThreshold{
+		{Scope: BudgetScopeDaily, Fraction: 0.5, Used: 2, Limit: 4},
+		{Scope: BudgetScopeDaily, Fraction: 1, Used: 4, Limit: 4},
+		{Scope: BudgetScopeTotal, Fraction: 0.5, Used: 5, Limit: 10},
+		{Scope: BudgetScopeDaily, Fraction: 0.5, Used: 2, Limit: 4},
+	}
+
+	if !reflect.DeepEqual(reached, want) {
+		t.Errorf("reached thresholds = %+v, want %+v", reached, want)
+	}
+}
@@ -0,0 +1,131 @@
+package websitecategorization
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/whois-api-llc/website-categorization-go/internal/lru"
+)
+
+// Cache stores parsed Website Categorization API responses.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the response stored under the key.
+	Get(key string) (*WCategorizationResponse, bool)
+
+	// Set stores the response under the key.
+	Set(key string, value *WCategorizationResponse)
+}
+
+// MemoryCache is the in-memory Cache with the limited number of entries and their lifetime.
+// The least recently used entries are evicted first.
+type MemoryCache struct {
+	lru *lru.Cache
+}
+
+var _ Cache = &MemoryCache{}
+
+// NewMemoryCache creates MemoryCache. Zero ttl means that entries never expire,
+// zero maxEntries means that the number of entries is not limited.
+func NewMemoryCache(ttl time.Duration, maxEntries int) *MemoryCache {
+	return &MemoryCache{lru: lru.New(ttl, maxEntries)}
+}
+
+// Get returns a copy of the response stored under the key if it has not expired yet.
+func (c *MemoryCache) Get(key string) (*WCategorizationResponse, bool) {
+	value, ok := c.lru.Get(key)
+	if !ok {
+		return nil, false
+	}
+
+	return value.(*WCategorizationResponse).clone(), true
+}
+
+// Set stores a copy of the response under the key.
+func (c *MemoryCache) Set(key string, value *WCategorizationResponse) {
+	c.lru.Set(key, value.clone())
+}
+
+// Len returns the number of stored entries including expired ones.
+func (c *MemoryCache) Len() int {
+	return c.lru.Len()
+}
+
+// cacheKey returns the Cache key for the domain name and the request options.
+// The output format is not a part of the key since Get always requests JSON.
+func cacheKey(domainName string, opts []Option) string {
+	query := url.Values{}
+	applyOptions(query, opts)
+	query.Del("outputFormat")
+
+	return strings.TrimSuffix(strings.ToLower(domainName), ".") + "?" + query.Encode()
+}
+
+// cachingService is the WCategorizationService that serves Get requests from the Cache.
+// Raw requests are passed through as is.
+type cachingService struct {
+	WCategorizationService
+
+	cache Cache
+
+	// minConfidence is the threshold responses are requested and cached with. Requests with a higher
+	// threshold are served by filtering the cached response. Zero means that responses are requested
+	// and cached with the threshold of each request.
+	minConfidence float64
+}
+
+// Get returns the cached response if there is one, otherwise it requests the API and caches the result.
+// The returned Response is nil when the result is served from the cache.
+func (s *cachingService) Get(
+	ctx context.Context,
+	domainName string,
+	opts ...Option,
+) (*WCategorizationResponse, *Response, error) {
+	if domainName == "" {
+		return nil, nil, &ArgError{"domainName", "can not be empty"}
+	}
+
+	if err := ValidateOptions(opts...); err != nil {
+		return nil, nil, err
+	}
+
+	threshold := requestedMinConfidence(opts)
+	if s.minConfidence <= 0 || threshold < s.minConfidence {
+		return s.get(ctx, domainName, opts)
+	}
+
+	lowOpts := make([]Option, 0, len(opts)+1)
+	lowOpts = append(lowOpts, opts...)
+	lowOpts = append(lowOpts, OptionMinConfidence(s.minConfidence))
+
+	wCategorizationResp, resp, err := s.get(ctx, domainName, lowOpts)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return wCategorizationResp.WithMinConfidence(threshold), resp, nil
+}
+
+// get returns the cached response for the options or requests and caches it.
+func (s *cachingService) get(
+	ctx context.Context,
+	domainName string,
+	opts []Option,
+) (*WCategorizationResponse, *Response, error) {
+	key := cacheKey(domainName, opts)
+
+	if cached, ok := s.cache.Get(key); ok {
+		return cached, nil, nil
+	}
+
+	wCategorizationResp, resp, err := s.WCategorizationService.Get(ctx, domainName, opts...)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	s.cache.Set(key, wCategorizationResp)
+
+	return wCategorizationResp, resp, nil
+}
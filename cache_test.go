@@ -0,0 +1,107 @@
+package websitecategorization
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestMemoryCache tests expiration and eviction of MemoryCache entries.
+func TestMemoryCache(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cache := NewMemoryCache(time.Minute, 2)
+	cache.lru.Now = func() time.Time { return now }
+
+	cache.Set("a", &WCategorizationResponse{DomainName: "a"})
+	cache.Set("b", &WCategorizationResponse{DomainName: "b"})
+
+	if _, ok := cache.Get("a"); !ok {
+		t.Fatal("MemoryCache.Get(a) is missing")
+	}
+
+	// "b" is the least recently used entry now.
+	cache.Set("c", &WCategorizationResponse{DomainName: "c"})
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("MemoryCache.Get(b) must be evicted")
+	}
+
+	got, ok := cache.Get("c")
+	if !ok || got.DomainName != "c" {
+		t.Errorf("MemoryCache.Get(c) = %v, %v", got, ok)
+	}
+
+	got.DomainName = "modified"
+	if got, _ = cache.Get("c"); got.DomainName != "c" {
+		t.Error("MemoryCache must return copies of the stored values")
+	}
+
+	now = now.Add(time.Minute)
+
+	if _, ok = cache.Get("a"); ok {
+		t.Error("MemoryCache.Get(a) must be expired")
+	}
+
+	if cache.Len() != 1 {
+		t.Errorf("MemoryCache.Len() = %d, want 1", cache.Len())
+	}
+}
+
+// TestCachingService tests the Client with the Cache.
+func TestCachingService(t *testing.T) {
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_, _ = w.Write([]byte(`{"domainName":"` + req.URL.Query().Get("domainName") +
+			`","categories":[{"confidence":0.85,"id":5,"name":"Computer and Internet Info"}],"websiteResponded":true}`))
+	}))
+	defer server.Close()
+
+	apiURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewClient(apiKey, ClientParams{
+		HTTPClient:             server.Client(),
+		WCategorizationBaseURL: apiURL,
+		Cache:                  NewMemoryCache(0, 0),
+	})
+
+	ctx := context.Background()
+
+	calls := []struct {
+		domainName string
+		opts       []Option
+	}{
+		{"whoisxmlapi.com", nil},
+		{"WhoisXMLAPI.com.", []Option{OptionOutputFormat("XML")}},
+		{"whoisxmlapi.com", []Option{OptionMinConfidence(0.8)}},
+		{"whoisxmlapi.com", []Option{OptionMinConfidence(0.8)}},
+	}
+
+	for _, call := range calls {
+		resp, _, err := client.Get(ctx, call.domainName, call.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(resp.Categories) != 1 {
+			t.Errorf("Client.Get() categories = %v", resp.Categories)
+		}
+	}
+
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("API requests = %d, want 2", got)
+	}
+
+	if _, _, err = client.Get(ctx, ""); err == nil {
+		t.Error("Client.Get() with empty domain name must fail")
+	}
+}
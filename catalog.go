@@ -0,0 +1,241 @@
+package websitecategorization
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Catalog is the translation of category names into a language.
+type Catalog struct {
+	locale string
+	names  map[int]string
+}
+
+// catalogFile is the JSON file with Catalog.
+type catalogFile struct {
+	Locale string            `json:"locale"`
+	Names  map[string]string `json:"names"`
+}
+
+// NewCatalog creates Catalog for the locale, e.g. "de" or "pt-BR", with the names keyed by category ID.
+func NewCatalog(locale string, names map[int]string) (*Catalog, error) {
+	if normalizeLocale(locale) == "" {
+		return nil, &ArgError{"locale", "must not be empty"}
+	}
+
+	c := &Catalog{locale: locale, names: make(map[int]string, len(names))}
+
+	for id, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			c.names[id] = name
+		}
+	}
+
+	return c, nil
+}
+
+// LoadCatalog creates Catalog from the JSON file with the locale and the names keyed by category ID, e.g.
+// {"locale": "de", "names": {"1": "Glücksspiel"}}.
+func LoadCatalog(path string) (*Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file catalogFile
+	if err = json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("cannot parse catalog %s: %w", path, err)
+	}
+
+	names := make(map[int]string, len(file.Names))
+
+	for key, name := range file.Names {
+		id, err := strconv.Atoi(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid catalog %s: %w", path, &ArgError{"names", "has invalid category ID " + key})
+		}
+
+		names[id] = name
+	}
+
+	c, err := NewCatalog(file.Locale, names)
+	if err != nil {
+		return nil, fmt.Errorf("invalid catalog %s: %w", path, err)
+	}
+
+	return c, nil
+}
+
+// Locale returns the catalog locale.
+func (c *Catalog) Locale() string {
+	return c.locale
+}
+
+// Name returns the translated name of the category.
+func (c *Catalog) Name(id int) (string, bool) {
+	name, ok := c.names[id]
+
+	return name, ok
+}
+
+// Missing returns the categories of the directory returned by GetAllCategories that have no translation,
+// sorted by ID.
+func (c *Catalog) Missing(categories []CategoryItem) []CategoryItem {
+	var missing []CategoryItem
+
+	for _, category := range categories {
+		if _, ok := c.names[category.ID]; !ok {
+			missing = append(missing, category)
+		}
+	}
+
+	sort.Slice(missing, func(i, j int) bool { return missing[i].ID < missing[j].ID })
+
+	return missing
+}
+
+// Catalogs is the set of catalogs in different languages.
+type Catalogs struct {
+	catalogs map[string]*Catalog
+}
+
+// NewCatalogs creates Catalogs. Later catalogs replace earlier ones with the same locale.
+func NewCatalogs(catalogs ...*Catalog) *Catalogs {
+	c := &Catalogs{catalogs: make(map[string]*Catalog, len(catalogs))}
+
+	for _, catalog := range catalogs {
+		c.catalogs[normalizeLocale(catalog.locale)] = catalog
+	}
+
+	return c
+}
+
+// LoadCatalogs creates Catalogs from the *.json files of the directory, see LoadCatalog.
+func LoadCatalogs(dir string) (*Catalogs, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	catalogs := make([]*Catalog, 0, len(paths))
+
+	for _, path := range paths {
+		catalog, err := LoadCatalog(path)
+		if err != nil {
+			return nil, err
+		}
+
+		catalogs = append(catalogs, catalog)
+	}
+
+	return NewCatalogs(catalogs...), nil
+}
+
+// Locales returns the sorted locales of the catalogs.
+func (c *Catalogs) Locales() []string {
+	locales := make([]string, 0, len(c.catalogs))
+	for _, catalog := range c.catalogs {
+		locales = append(locales, catalog.locale)
+	}
+
+	sort.Strings(locales)
+
+	return locales
+}
+
+// Catalog returns the catalog of the locale. The locale is matched case-insensitively, e.g. "pt_br" matches
+// "pt-BR", and falls back to its language, e.g. "de-AT" matches "de".
+func (c *Catalogs) Catalog(locale string) (*Catalog, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	locale = normalizeLocale(locale)
+
+	for locale != "" {
+		if catalog, ok := c.catalogs[locale]; ok {
+			return catalog, true
+		}
+
+		dash := strings.LastIndexByte(locale, '-')
+		if dash < 0 {
+			break
+		}
+
+		locale = locale[:dash]
+	}
+
+	return nil, false
+}
+
+// Match returns the locale of the catalog best matching the Accept-Language header value,
+// e.g. "fr-CH, de;q=0.9, en;q=0.8". It returns an empty string if no catalog matches.
+func (c *Catalogs) Match(acceptLanguage string) string {
+	type tag struct {
+		locale string
+		q      float64
+	}
+
+	var tags []tag
+
+	for _, field := range strings.Split(acceptLanguage, ",") {
+		parts := strings.Split(field, ";")
+
+		t := tag{locale: strings.TrimSpace(parts[0]), q: 1}
+
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					t.q = q
+				}
+			}
+		}
+
+		if t.locale != "" && t.locale != "*" && t.q > 0 {
+			tags = append(tags, t)
+		}
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	for _, t := range tags {
+		if catalog, ok := c.Catalog(t.locale); ok {
+			return catalog.locale
+		}
+	}
+
+	return ""
+}
+
+// Name returns the name of the category translated into the locale, see Catalog.
+// It returns the English name if there is no translation.
+func (c *Catalogs) Name(id int, locale, english string) string {
+	if catalog, ok := c.Catalog(locale); ok {
+		if name, ok := catalog.Name(id); ok {
+			return name
+		}
+	}
+
+	return english
+}
+
+// normalizeLocale returns the lowercase locale with dashes as separators.
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+// LocalizedName returns the category name translated into the locale or the API name if there is no translation.
+func (c Category) LocalizedName(catalogs *Catalogs, locale string) string {
+	return catalogs.Name(c.ID, locale, c.Name)
+}
+
+// LocalizedName returns the category name translated into the locale or the API name if there is no translation.
+func (c CategoryItem) LocalizedName(catalogs *Catalogs, locale string) string {
+	return catalogs.Name(c.ID, locale, c.Name)
+}
@@ -0,0 +1,108 @@
+package websitecategorization
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestLocalizedName tests the translation of category names.
+func TestLocalizedName(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"de.json":    `{"locale": "de", "names": {"1": "Glücksspiel", "3": "Einkaufen"}}`,
+		"pt-BR.json": `{"locale": "pt-BR", "names": {"1": "Jogos de azar"}}`,
+		"notes.txt":  `not a catalog`,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	catalogs, err := LoadCatalogs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := catalogs.Locales(); !reflect.DeepEqual(got, []string{"de", "pt-BR"}) {
+		t.Errorf("Locales() = %v", got)
+	}
+
+	tests := []struct {
+		locale string
+		id     int
+		want   string
+	}{
+		{locale: "de", id: 1, want: "Glücksspiel"},
+		{locale: "DE-at", id: 3, want: "Einkaufen"},
+		{locale: "pt_br", id: 1, want: "Jogos de azar"},
+		{locale: "pt", id: 1, want: "Gambling"},
+		{locale: "de", id: 2, want: "Educational Institutions"},
+		{locale: "fr", id: 1, want: "Gambling"},
+		{locale: "", id: 1, want: "Gambling"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			item := testDirectory[tt.id-1]
+
+			if got := item.LocalizedName(catalogs, tt.locale); got != tt.want {
+				t.Errorf("CategoryItem.LocalizedName() = %q, want %q", got, tt.want)
+			}
+
+			category := Category{ID: item.ID, Name: item.Name, Confidence: 0.9}
+			if got := category.LocalizedName(catalogs, tt.locale); got != tt.want {
+				t.Errorf("Category.LocalizedName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	for acceptLanguage, want := range map[string]string{
+		"fr-CH, de-DE;q=0.9, pt-BR;q=0.8": "de",
+		"pt-br;q=0.5, de;q=0.4":           "pt-BR",
+		"fr, *;q=0.1":                     "",
+		"de;q=0":                          "",
+	} {
+		if got := catalogs.Match(acceptLanguage); got != want {
+			t.Errorf("Match(%q) = %q, want %q", acceptLanguage, got, want)
+		}
+	}
+
+	if got := testDirectory[0].LocalizedName(nil, "de"); got != "Gambling" {
+		t.Errorf("LocalizedName() without catalogs = %q", got)
+	}
+}
+
+// TestCatalogMissing tests checking catalogs against the category directory.
+func TestCatalogMissing(t *testing.T) {
+	catalog, err := NewCatalog("de", map[int]string{1: "Glücksspiel", 4: "Regierung", 5: " "})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []int
+	for _, category := range catalog.Missing(testDirectory) {
+		ids = append(ids, category.ID)
+	}
+
+	if !reflect.DeepEqual(ids, []int{2, 3, 5}) {
+		t.Errorf("Missing() = %v, want [2 3 5]", ids)
+	}
+
+	var argErr *ArgError
+	if _, err = NewCatalog(" ", nil); !errors.As(err, &argErr) {
+		t.Errorf("NewCatalog() error = %v, want ArgError", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "bad.json")
+	if err = os.WriteFile(path, []byte(`{"locale": "de", "names": {"x": "y"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = LoadCatalog(path); !errors.As(err, &argErr) {
+		t.Errorf("LoadCatalog() error = %v, want ArgError", err)
+	}
+}
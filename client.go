@@ -27,6 +27,42 @@ type ClientParams struct {
 
 	// WCategorizationBaseURL is the endpoint for 'Website Categorization API' service
 	WCategorizationBaseURL *url.URL
+
+	// Cache is used to store responses returned by Get
+	// If it's nil then responses are not cached
+	Cache Cache
+
+	// CacheMinConfidence is the minimum confidence responses are requested and cached with
+	// Requests with a higher OptionMinConfidence are served from the same cache entry
+	// If it's zero then every threshold is requested and cached separately
+	CacheMinConfidence float64
+
+	// Budget limits the number of billable requests
+	// If it's nil then the number of requests is not limited
+	Budget *Budget
+
+	// APIKeys are the API keys used in addition to the one passed to NewClient
+	// A key rejected by the API is not used for a while and the request is repeated with another key
+	APIKeys []APIKey
+
+	// KeyRotation is the strategy of choosing the API key for a request
+	// Default: KeyRotationFailover
+	KeyRotation KeyRotation
+
+	// DefaultOptions are added to every request before the options passed to the request
+	// Only options applicable to the requested endpoint are added
+	DefaultOptions []Option
+
+	// StrictOptions makes requests with options inapplicable to the endpoint fail with ArgError
+	// Otherwise such options are dropped from the request
+	StrictOptions bool
+
+	// OptionWarning is called with ArgError for every inapplicable option dropped from a request
+	OptionWarning func(err error)
+
+	// Decorators wrap the service of the client, the first one is the outermost
+	// They are called after the default options are added and before the cache is looked up
+	Decorators []Decorator
 }
 
 // NewBasicClient creates Client with recommended parameters.
@@ -51,13 +87,33 @@ func NewClient(apiKey string, params ClientParams) *Client {
 		httpClient = params.HTTPClient
 	}
 
+	keys := make([]APIKey, 0, len(params.APIKeys)+1)
+	keys = append(keys, APIKey{Key: apiKey})
+	keys = append(keys, params.APIKeys...)
+
 	client := &Client{
 		client:    httpClient,
 		userAgent: userAgent,
-		apiKey:    apiKey,
+		keys:      newKeyPool(keys, params.KeyRotation),
+		budget:    params.Budget,
+
+		strictOptions: params.StrictOptions,
+		optionWarning: params.OptionWarning,
+	}
+
+	var decorators []Decorator
+
+	if len(params.DefaultOptions) > 0 {
+		decorators = append(decorators, WithDefaultOptions(params.DefaultOptions...))
+	}
+
+	decorators = append(decorators, params.Decorators...)
+
+	if params.Cache != nil {
+		decorators = append(decorators, WithCache(params.Cache, params.CacheMinConfidence))
 	}
 
-	client.WCategorizationService = &wCategorizationServiceOp{client: client, baseURL: apiBaseURL}
+	client.WCategorizationService = Decorate(newWCategorizationServiceOp(client, apiBaseURL), decorators...)
 
 	return client
 }
@@ -67,12 +123,21 @@ type Client struct {
 	client *http.Client
 
 	userAgent string
-	apiKey    string
+	keys      *keyPool
+	budget    *Budget
+
+	strictOptions bool
+	optionWarning func(err error)
 
 	// WCategorization is an interface for Website Categorization API
 	WCategorizationService
 }
 
+// KeyStatuses returns the health of the API keys.
+func (c *Client) KeyStatuses() []KeyStatus {
+	return c.keys.statuses()
+}
+
 // NewRequest creates a basic API request.
 func (c *Client) NewRequest(method string, u *url.URL, body io.Reader) (*http.Request, error) {
 	var err error
@@ -97,7 +162,7 @@ func (c *Client) Do(ctx context.Context, req *http.Request, v io.Writer) (respon
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("cannot execute request: %w", err)
+		return nil, fmt.Errorf("cannot execute request: %w", redactError(err))
 	}
 
 	defer func() {
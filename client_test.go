@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"strconv"
+	"sync"
 	"testing"
 )
 
@@ -616,3 +617,78 @@ func TestGetAllCategoriesRaw(t *testing.T) {
 		})
 	}
 }
+
+// TestSharedClient tests that a single Client serves both endpoints repeatedly and concurrently.
+func TestSharedClient(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		paths = make(map[string]int)
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		paths[req.URL.Path]++
+		mu.Unlock()
+
+		switch req.URL.Path {
+		case "/api/v3/":
+			_, _ = w.Write([]byte(`{"domainName":"whoisxmlapi.com","categories":[]}`))
+		case "/api/v3/categories":
+			_, _ = w.Write([]byte(`[{"id":0,"name":"Uncategorized"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	apiURL, err := url.Parse(server.URL + "/api/v3/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	api := NewClient(apiKey, ClientParams{
+		HTTPClient:             server.Client(),
+		WCategorizationBaseURL: apiURL,
+	})
+
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, 40)
+
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			_, _, err := api.GetAllCategories(ctx)
+			errs <- err
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			_, _, err := api.Get(ctx, "whoisxmlapi.com")
+			errs <- err
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	if paths["/api/v3/"] != 20 || paths["/api/v3/categories"] != 20 || len(paths) != 2 {
+		t.Errorf("requested paths = %v", paths)
+	}
+
+	if apiURL.Path != "/api/v3/" {
+		t.Errorf("base URL path changed to %s", apiURL.Path)
+	}
+}
@@ -0,0 +1,151 @@
+// Command wcategorization-blocklist turns categorization results into blocklists of DNS servers
+// and content filters.
+//
+// Usage:
+//
+//	wcategorization-blocklist -input results.jsonl -deny 10,11 -format rpz -allowlist allow.txt -output blocked.rpz
+//
+// The input is the JSON Lines or CSV file written by wcategorization-bulk or the export functions.
+// Supported formats are hosts, dnsmasq, unbound, rpz and adblock. The allowlist file contains one domain
+// per line, empty lines and lines starting with # are skipped.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"strings"
+
+	websitecategorization "github.com/whois-api-llc/website-categorization-go"
+	"github.com/whois-api-llc/website-categorization-go/internal/cliutil"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("wcategorization-blocklist: ")
+
+	if err := run(os.Args, os.Stdin, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run generates the blocklist according to the command line arguments. The standard input and output
+// are used when the input or the output is "-".
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	var (
+		policyFlags cliutil.PolicyFlags
+
+		blocklist     websitecategorization.Blocklist
+		input         string
+		inputFormat   string
+		output        string
+		format        string
+		allowlistPath string
+		rpzSerial     uint64
+	)
+
+	fs := flag.NewFlagSet(args[0], flag.ExitOnError)
+	policyFlags.Register(fs)
+	fs.StringVar(&input, "input", "-", "file with categorization results, - for the standard input")
+	fs.StringVar(&inputFormat, "input-format", "jsonl", "input format: jsonl, csv or csv-per-category")
+	fs.StringVar(&output, "output", "-", "blocklist file, - for the standard output")
+	fs.StringVar(&format, "format", string(websitecategorization.BlocklistHosts), "blocklist format: "+formatNames())
+	fs.StringVar(&allowlistPath, "allowlist", "", "file with domains that are never blocked, one per line")
+	fs.StringVar(&blocklist.SinkholeAddress, "sinkhole", "0.0.0.0", "address of blocked names in hosts files")
+	fs.Uint64Var(&rpzSerial, "rpz-serial", 1, "serial number of the RPZ zone")
+	_ = fs.Parse(args[1:])
+
+	var err error
+
+	if blocklist.Policy, err = policyFlags.Policy(); err != nil {
+		return err
+	}
+
+	if rpzSerial > math.MaxUint32 {
+		return fmt.Errorf("-rpz-serial must not be greater than %d", uint32(math.MaxUint32))
+	}
+
+	blocklist.RPZSerial = uint32(rpzSerial)
+
+	if allowlistPath != "" {
+		if blocklist.Allowlist, err = readAllowlist(allowlistPath); err != nil {
+			return err
+		}
+	}
+
+	in := stdin
+	if input != "-" {
+		file, err := os.Open(input)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		in = file
+	}
+
+	reader, err := newReader(in, inputFormat)
+	if err != nil {
+		return err
+	}
+
+	if _, err = blocklist.AddFrom(reader); err != nil {
+		return err
+	}
+
+	if output == "-" {
+		return blocklist.Write(stdout, websitecategorization.BlocklistFormat(format))
+	}
+
+	// The file is replaced atomically, so the previous blocklist stays in place if generation fails.
+	return blocklist.WriteFile(output, websitecategorization.BlocklistFormat(format))
+}
+
+// newReader returns the reader of the results in the format.
+func newReader(r io.Reader, format string) (websitecategorization.ResponseReader, error) {
+	switch format {
+	case "jsonl":
+		return websitecategorization.NewJSONLReader(r), nil
+	case "csv":
+		return websitecategorization.NewCSVReader(r, websitecategorization.CSVRowPerDomain), nil
+	case "csv-per-category":
+		return websitecategorization.NewCSVReader(r, websitecategorization.CSVRowPerCategory), nil
+	}
+
+	return nil, fmt.Errorf("unknown input format %q", format)
+}
+
+// readAllowlist returns the domains of the allowlist file.
+func readAllowlist(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var domains []string
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			domains = append(domains, line)
+		}
+	}
+
+	return domains, scanner.Err()
+}
+
+// formatNames returns the comma-separated names of the blocklist formats.
+func formatNames() string {
+	names := make([]string, len(websitecategorization.BlocklistFormats))
+	for i, format := range websitecategorization.BlocklistFormats {
+		names[i] = string(format)
+	}
+
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	websitecategorization "github.com/whois-api-llc/website-categorization-go"
+)
+
+// writeTestFile writes the file into the test directory and returns its path.
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+// TestNewReader tests reading the results in every input format.
+func TestNewReader(t *testing.T) {
+	tests := []struct {
+		format  string
+		input   string
+		want    map[string]int
+		wantErr bool
+	}{
+		{
+			format: "jsonl",
+			input: `{"domainName":"casino.com","categories":[{"id":10,"confidence":0.9},{"id":3,"confidence":0.5}]}
+{"domainName":"shop.com","categories":[{"id":3,"confidence":0.9}]}
+`,
+			want: map[string]int{"casino.com": 2, "shop.com": 1},
+		},
+		{
+			format: "csv",
+			input:  "domainName,categories.id,categories.confidence\ncasino.com,10|3,0.9|0.5\nshop.com,3,0.9\n",
+			want:   map[string]int{"casino.com": 2, "shop.com": 1},
+		},
+		{
+			format: "csv-per-category",
+			input:  "domainName,categories.id,categories.confidence\ncasino.com,10,0.9\ncasino.com,3,0.5\nshop.com,3,0.9\n",
+			want:   map[string]int{"casino.com": 2, "shop.com": 1},
+		},
+		{
+			format:  "xml",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			reader, err := newReader(strings.NewReader(tt.input), tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newReader() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			got := make(map[string]int)
+
+			for {
+				resp, err := reader.Read()
+				if err == io.EOF {
+					break
+				}
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				got[resp.DomainName] = len(resp.Categories)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("categories per domain = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestReadAllowlist tests that comments and empty lines of the allowlist are skipped.
+func TestReadAllowlist(t *testing.T) {
+	path := writeTestFile(t, "allow.txt", "# partners\npartner.org\n\n  help.casino.com  \n")
+
+	got, err := readAllowlist(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"partner.org", "help.casino.com"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("readAllowlist() = %v, want %v", got, want)
+	}
+
+	if _, err = readAllowlist(filepath.Join(t.TempDir(), "missing.txt")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("readAllowlist() error = %v, want ErrNotExist", err)
+	}
+}
+
+// TestRun tests the blocklists generated according to the flags.
+func TestRun(t *testing.T) {
+	input := writeTestFile(t, "results.jsonl",
+		`{"domainName":"casino.com","categories":[{"id":10,"confidence":0.9}]}
+{"domainName":"partner.org","categories":[{"id":10,"confidence":0.9}]}
+{"domainName":"shop.com","categories":[{"id":3,"confidence":0.9}]}
+`)
+	allowlist := writeTestFile(t, "allow.txt", "partner.org\nhelp.casino.com\n")
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "hosts with sinkhole",
+			args: []string{"-deny", "10", "-allowlist", allowlist, "-sinkhole", "127.0.0.1"},
+			want: "127.0.0.1 casino.com\n",
+		},
+		{
+			name: "rpz serial",
+			args: []string{"-deny", "10", "-format", "rpz", "-rpz-serial", "2021010101"},
+			want: "$TTL 300\n@ IN SOA localhost. root.localhost. 2021010101 3600 600 86400 300\n@ IN NS localhost.\n" +
+				"casino.com CNAME .\n*.casino.com CNAME .\npartner.org CNAME .\n*.partner.org CNAME .\n",
+		},
+		{
+			name: "allowlist exceptions",
+			args: []string{"-deny", "10", "-format", "adblock", "-allowlist", allowlist},
+			want: "[Adblock Plus 2.0]\n||casino.com^\n@@||help.casino.com^\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+
+			args := append([]string{"wcategorization-blocklist", "-input", input}, tt.args...)
+			if err := run(args, strings.NewReader(""), &stdout); err != nil {
+				t.Fatal(err)
+			}
+
+			if stdout.String() != tt.want {
+				t.Errorf("blocklist =\n%s\nwant\n%s", stdout.String(), tt.want)
+			}
+		})
+	}
+}
+
+// TestRunInvalidSerial tests that the RPZ serial out of the 32-bit range is rejected.
+func TestRunInvalidSerial(t *testing.T) {
+	var stdout bytes.Buffer
+
+	err := run([]string{"wcategorization-blocklist", "-format", "rpz", "-rpz-serial", "4294967296"},
+		strings.NewReader(""), &stdout)
+	if err == nil || !strings.Contains(err.Error(), "-rpz-serial") {
+		t.Errorf("run() error = %v, want -rpz-serial error", err)
+	}
+
+	if stdout.Len() != 0 {
+		t.Errorf("blocklist = %q, want nothing", stdout.String())
+	}
+}
+
+// TestRunOutputFile tests that the output file is replaced only with the complete blocklist.
+func TestRunOutputFile(t *testing.T) {
+	output := writeTestFile(t, "blocked.hosts", "0.0.0.0 previous.com\n")
+	stdin := `{"domainName":"casino.com","categories":[{"id":10,"confidence":0.9}]}` + "\n"
+
+	err := run([]string{"wcategorization-blocklist", "-deny", "10", "-format", "squid", "-output", output},
+		strings.NewReader(stdin), &bytes.Buffer{})
+	if !errors.Is(err, websitecategorization.ErrUnknownBlocklistFormat) {
+		t.Errorf("run() error = %v, want ErrUnknownBlocklistFormat", err)
+	}
+
+	if data, _ := os.ReadFile(output); string(data) != "0.0.0.0 previous.com\n" {
+		t.Errorf("failed run changed the blocklist file: %q", data)
+	}
+
+	if err = run([]string{"wcategorization-blocklist", "-deny", "10", "-output", output},
+		strings.NewReader(stdin), &bytes.Buffer{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, _ := os.ReadFile(output); string(data) != "0.0.0.0 casino.com\n" {
+		t.Errorf("blocklist file = %q", data)
+	}
+}
@@ -0,0 +1,89 @@
+// Command wcategorization-bulk categorizes every domain of the input file and writes the
+// results to the JSON Lines file.
+//
+// Usage:
+//
+//	wcategorization-bulk -input domains.txt -output results.jsonl -concurrency 4
+//
+// The job can be interrupted with Ctrl+C at any time. Running the same command again continues
+// from the last checkpoint without requesting completed domains. Domains that could not be
+// categorized are written to the retry file (results.jsonl.retry by default).
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	websitecategorization "github.com/whois-api-llc/website-categorization-go"
+	"github.com/whois-api-llc/website-categorization-go/internal/cliutil"
+)
+
+func main() {
+	logger := log.New(os.Stderr, "wcategorization-bulk: ", log.LstdFlags)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+
+	err := run(ctx, os.Args, logger)
+
+	stop()
+
+	if err != nil {
+		logger.Fatal(err)
+	}
+}
+
+// run runs the job according to the command line arguments until it completes or the context is canceled.
+// The queued webhook events are delivered before it returns, even if the job fails.
+func run(ctx context.Context, args []string, logger *log.Logger) error {
+	var (
+		clientFlags  cliutil.ClientFlags
+		webhookFlags cliutil.WebhookFlags
+
+		job           websitecategorization.Job
+		minConfidence float64
+	)
+
+	fs := flag.NewFlagSet(args[0], flag.ExitOnError)
+	clientFlags.Register(fs)
+	webhookFlags.Register(fs)
+	fs.StringVar(&job.InputPath, "input", "", "file with domain names, one per line")
+	fs.StringVar(&job.OutputPath, "output", "", "JSON Lines file with categorization results")
+	fs.StringVar(&job.CheckpointPath, "checkpoint", "", "checkpoint file, defaults to the output file with .checkpoint suffix")
+	fs.DurationVar(&job.CheckpointInterval, "checkpoint-interval", 5*time.Second, "period of checkpoint saves")
+	fs.StringVar(&job.RetryPath, "retry", "", "file with failed domains, defaults to the output file with .retry suffix")
+	fs.IntVar(&job.Concurrency, "concurrency", 1, "number of simultaneous requests")
+	fs.DurationVar(&job.ProgressInterval, "progress-interval", 10*time.Second, "period of progress reports")
+	fs.Float64Var(&minConfidence, "min-confidence", 0, "minimum confidence of returned categories, "+
+		"the API default is used if zero")
+	_ = fs.Parse(args[1:])
+
+	notifier, err := webhookFlags.NewNotifier(ctx, logger)
+	if err != nil {
+		return err
+	}
+	defer notifier.Close()
+
+	client, err := clientFlags.NewClient(ctx, logger, notifier)
+	if err != nil {
+		return err
+	}
+
+	job.Service = client
+
+	if minConfidence > 0 {
+		job.Options = append(job.Options, websitecategorization.OptionMinConfidence(minConfidence))
+	}
+
+	job.Progress = func(p websitecategorization.JobProgress) {
+		logger.Printf("processed %d/%d lines, succeeded %d, failed %d, elapsed %s, ETA %s",
+			p.Processed, p.Total, p.Succeeded, p.Failed, p.Elapsed.Round(time.Second), p.ETA.Round(time.Second))
+	}
+
+	_, err = job.Run(ctx)
+
+	return err
+}
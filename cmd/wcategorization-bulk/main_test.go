@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	websitecategorization "github.com/whois-api-llc/website-categorization-go"
+	"github.com/whois-api-llc/website-categorization-go/internal/apitest"
+)
+
+// TestRunResume tests that the job stopped by the budget continues from the checkpoint and that
+// the webhook events are delivered when the run fails.
+func TestRunResume(t *testing.T) {
+	api := apitest.NewServer(t, map[string][]websitecategorization.Category{
+		"a.test": {{ID: 1, Name: "Arts", Confidence: 0.9}},
+		"b.test": {{ID: 2, Name: "Business", Confidence: 0.9}},
+		"c.test": {{ID: 3, Name: "Shopping", Confidence: 0.9}},
+	})
+	api.RequireAPIKey("at_test")
+
+	var (
+		mu     sync.Mutex
+		events []websitecategorization.WebhookEvent
+	)
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var event websitecategorization.WebhookEvent
+		_ = json.NewDecoder(req.Body).Decode(&event)
+
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	}))
+	defer receiver.Close()
+
+	dir := t.TempDir()
+
+	input := filepath.Join(dir, "domains.txt")
+	output := filepath.Join(dir, "results.jsonl")
+
+	domains := "a.test\n" + apitest.UnreachableDomain + "\nb.test\nc.test\n"
+	if err := os.WriteFile(input, []byte(domains), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	args := func(budget string) []string {
+		return []string{
+			"wcategorization-bulk",
+			"-api-key", "at_test",
+			"-api-url", api.URL,
+			"-input", input,
+			"-output", output,
+			"-budget-total", budget,
+			"-budget-state", filepath.Join(dir, "budget.json"),
+			"-webhook-url", receiver.URL,
+		}
+	}
+
+	logger := log.New(io.Discard, "", 0)
+
+	err := run(context.Background(), args("2"), logger)
+	if !errors.Is(err, websitecategorization.ErrBudgetExceeded) {
+		t.Fatalf("run() error = %v, want ErrBudgetExceeded", err)
+	}
+
+	if n := api.Requests(); n != 2 {
+		t.Errorf("API requests = %d, want 2", n)
+	}
+
+	mu.Lock()
+	if len(events) == 0 || events[0].Type != websitecategorization.EventBudgetThreshold {
+		t.Errorf("events = %+v, want budget thresholds", events)
+	}
+	mu.Unlock()
+
+	if err = run(context.Background(), args("10"), logger); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := api.Requests(); n != 4 {
+		t.Errorf("API requests = %d, want 4", n)
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var resp websitecategorization.WCategorizationResponse
+		if err = json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Fatal(err)
+		}
+
+		got = append(got, resp.DomainName)
+	}
+
+	if strings.Join(got, ",") != "a.test,b.test,c.test" {
+		t.Errorf("output domains = %v", got)
+	}
+
+	retry, err := os.ReadFile(output + ".retry")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(retry) != apitest.UnreachableDomain+"\n" {
+		t.Errorf("retry file = %q", retry)
+	}
+}
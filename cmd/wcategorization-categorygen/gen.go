@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	websitecategorization "github.com/whois-api-llc/website-categorization-go"
+)
+
+// constant is the generated constant of a category.
+type constant struct {
+	Ident string
+	ID    int
+	Name  string
+}
+
+// generator holds the parameters of the generated file.
+type generator struct {
+	Package string
+	Type    string
+	Prefix  string
+	Source  string
+}
+
+// namesVar returns the name of the variable mapping the IDs to the category names.
+func (g *generator) namesVar() string {
+	return strings.ToLower(g.Type[:1]) + g.Type[1:] + "Names"
+}
+
+// constants returns the constants of the categories sorted by ID. Identifiers are derived from the names,
+// e.g. "Computer and Internet Info" becomes CategoryComputerAndInternetInfo. The ID is appended to
+// the identifiers that would be duplicated. The prefix must be a valid identifier.
+func (g *generator) constants(categories []websitecategorization.CategoryItem) []constant {
+	sorted := make([]websitecategorization.CategoryItem, len(categories))
+	copy(sorted, categories)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	constants := make([]constant, 0, len(sorted))
+	seen := make(map[string]bool, len(sorted))
+
+	for _, category := range sorted {
+		ident := g.Prefix + identifier(category.Name)
+		if ident == g.Prefix || seen[ident] {
+			ident += strconv.Itoa(category.ID)
+		}
+
+		seen[ident] = true
+
+		constants = append(constants, constant{Ident: ident, ID: category.ID, Name: category.Name})
+	}
+
+	return constants
+}
+
+// identifier returns the CamelCase identifier made of the letters and digits of the name.
+func identifier(name string) string {
+	var b strings.Builder
+
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+
+	return b.String()
+}
+
+// fileTemplate is the template of the generated file.
+var fileTemplate = template.Must(template.New("file").Parse(`// Code generated by wcategorization-categorygen; DO NOT EDIT.
+{{- if .Source}}
+// Source: {{.Source}}
+{{- end}}
+
+package {{.Package}}
+
+import "strconv"
+
+// {{.Type}} is the Website Categorization API category identifier.
+type {{.Type}} int
+
+// Categories of the Website Categorization API.
+const (
+{{- range .Constants}}
+	// {{.Ident}} is the {{printf "%q" .Name}} category.
+	{{.Ident}} {{$.Type}} = {{.ID}}
+{{- end}}
+)
+
+// {{.NamesVar}} maps the category IDs to the category names.
+var {{.NamesVar}} = map[{{.Type}}]string{
+{{- range .Constants}}
+	{{.Ident}}: {{printf "%q" .Name}},
+{{- end}}
+}
+
+// String returns the category name.
+func (id {{.Type}}) String() string {
+	if name, ok := {{.NamesVar}}[id]; ok {
+		return name
+	}
+
+	return "{{.Type}}(" + strconv.Itoa(int(id)) + ")"
+}
+
+// Parse{{.Type}} returns the ID of the category with the name.
+func Parse{{.Type}}(name string) ({{.Type}}, bool) {
+	for id, n := range {{.NamesVar}} {
+		if n == name {
+			return id, true
+		}
+	}
+
+	return 0, false
+}
+`))
+
+// generate returns the formatted source of the file with the constants.
+func (g *generator) generate(constants []constant) ([]byte, error) {
+	var buf bytes.Buffer
+
+	err := fileTemplate.Execute(&buf, struct {
+		*generator
+		NamesVar  string
+		Constants []constant
+	}{g, g.namesVar(), constants})
+	if err != nil {
+		return nil, err
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+// parse returns the constants of the previously generated file keyed by ID.
+func (g *generator) parse(src []byte) (map[int]constant, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]int)
+	previous := make(map[int]constant)
+
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+
+		for _, spec := range gen.Specs {
+			value, ok := spec.(*ast.ValueSpec)
+			if !ok || len(value.Names) != 1 || len(value.Values) != 1 {
+				continue
+			}
+
+			switch lit := value.Values[0].(type) {
+			case *ast.BasicLit:
+				if typ, ok := value.Type.(*ast.Ident); gen.Tok == token.CONST && ok && typ.Name == g.Type &&
+					lit.Kind == token.INT {
+					if id, err := strconv.Atoi(lit.Value); err == nil {
+						ids[value.Names[0].Name] = id
+					}
+				}
+			case *ast.CompositeLit:
+				if value.Names[0].Name != g.namesVar() {
+					continue
+				}
+
+				for _, elt := range lit.Elts {
+					kv, ok := elt.(*ast.KeyValueExpr)
+					if !ok {
+						continue
+					}
+
+					key, ok := kv.Key.(*ast.Ident)
+					if !ok {
+						continue
+					}
+
+					name, ok := kv.Value.(*ast.BasicLit)
+					if !ok {
+						continue
+					}
+
+					id, ok := ids[key.Name]
+					if !ok {
+						continue
+					}
+
+					unquoted, err := strconv.Unquote(name.Value)
+					if err != nil {
+						return nil, err
+					}
+
+					previous[id] = constant{Ident: key.Name, ID: id, Name: unquoted}
+				}
+			}
+		}
+	}
+
+	return previous, nil
+}
+
+// change describes a category that differs from the previously generated file.
+type change struct {
+	previous constant
+	current  *constant
+}
+
+// String returns the description of the change.
+func (c change) String() string {
+	if c.current == nil {
+		return fmt.Sprintf("category %d %q (%s) is removed", c.previous.ID, c.previous.Name, c.previous.Ident)
+	}
+
+	return fmt.Sprintf("category %d is renamed from %q (%s) to %q (%s)",
+		c.previous.ID, c.previous.Name, c.previous.Ident, c.current.Name, c.current.Ident)
+}
+
+// compare returns the removed and renamed categories sorted by ID.
+func compare(previous map[int]constant, constants []constant) []change {
+	current := make(map[int]constant, len(constants))
+	for _, c := range constants {
+		current[c.ID] = c
+	}
+
+	var changes []change
+
+	for id, p := range previous {
+		c, ok := current[id]
+
+		switch {
+		case !ok:
+			changes = append(changes, change{previous: p})
+		case c.Name != p.Name || c.Ident != p.Ident:
+			changes = append(changes, change{previous: p, current: &c})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].previous.ID < changes[j].previous.ID })
+
+	return changes
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+
+	websitecategorization "github.com/whois-api-llc/website-categorization-go"
+)
+
+var testCategories = []websitecategorization.CategoryItem{
+	{ID: 5, Name: "Computer and Internet Info"},
+	{ID: 1, Name: "Web-based Email"},
+	{ID: 7, Name: "News"},
+	{ID: 9, Name: "News"},
+	{ID: 12, Name: "3D Printing"},
+	{ID: 0, Name: ""},
+}
+
+// TestConstants tests the identifiers derived from the category names.
+func TestConstants(t *testing.T) {
+	g := &generator{Type: "CategoryID", Prefix: "Category"}
+
+	want := []constant{
+		{Ident: "Category0", ID: 0},
+		{Ident: "CategoryWebBasedEmail", ID: 1, Name: "Web-based Email"},
+		{Ident: "CategoryComputerAndInternetInfo", ID: 5, Name: "Computer and Internet Info"},
+		{Ident: "CategoryNews", ID: 7, Name: "News"},
+		{Ident: "CategoryNews9", ID: 9, Name: "News"},
+		{Ident: "Category3DPrinting", ID: 12, Name: "3D Printing"},
+	}
+
+	if got := g.constants(testCategories); !reflect.DeepEqual(got, want) {
+		t.Errorf("constants() = %v, want %v", got, want)
+	}
+}
+
+// TestGenerate tests the generated source and the detection of changed categories.
+func TestGenerate(t *testing.T) {
+	g := &generator{Package: "policy", Type: "CategoryID", Prefix: "Category", Source: "categories.json"}
+
+	src, err := g.generate(g.constants(testCategories))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = parser.ParseFile(token.NewFileSet(), "", src, 0); err != nil {
+		t.Fatalf("generated source is invalid: %v\n%s", err, src)
+	}
+
+	for _, want := range []string{
+		"// Code generated by wcategorization-categorygen; DO NOT EDIT.",
+		"package policy",
+		"CategoryComputerAndInternetInfo CategoryID = 5",
+		`CategoryWebBasedEmail:           "Web-based Email",`,
+		"func ParseCategoryID(name string) (CategoryID, bool)",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("generated source does not contain %q:\n%s", want, src)
+		}
+	}
+
+	previous, err := g.parse(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(previous) != len(testCategories) || previous[5].Ident != "CategoryComputerAndInternetInfo" ||
+		previous[5].Name != "Computer and Internet Info" {
+		t.Errorf("parse() = %v", previous)
+	}
+
+	if changes := compare(previous, g.constants(testCategories)); len(changes) != 0 {
+		t.Errorf("compare() with the same categories = %v", changes)
+	}
+
+	updated := []websitecategorization.CategoryItem{
+		{ID: 0, Name: ""},
+		{ID: 1, Name: "Web-based Email"},
+		{ID: 5, Name: "Computer and Internet"},
+		{ID: 9, Name: "News"},
+		{ID: 12, Name: "3D Printing"},
+		{ID: 20, Name: "Added"},
+	}
+
+	var got []string
+	for _, c := range compare(previous, g.constants(updated)) {
+		got = append(got, c.String())
+	}
+
+	want := []string{
+		`category 5 is renamed from "Computer and Internet Info" (CategoryComputerAndInternetInfo) to ` +
+			`"Computer and Internet" (CategoryComputerAndInternet)`,
+		`category 7 "News" (CategoryNews) is removed`,
+		`category 9 is renamed from "News" (CategoryNews9) to "News" (CategoryNews)`,
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("compare() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,127 @@
+// Command wcategorization-categorygen generates Go constants of the category IDs from the category directory,
+// so policies refer to categories by name instead of numbers.
+//
+// Usage with go generate:
+//
+//	//go:generate go run github.com/whois-api-llc/website-categorization-go/cmd/wcategorization-categorygen -input categories.json -output categories_gen.go
+//
+// The categories are read from the saved /categories response or requested from the API when no input
+// file is specified. Categories that are removed or renamed compared to the previously generated output
+// file are reported, -fail-on-change makes the command fail without overwriting the file.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/token"
+	"log"
+	"os"
+	"time"
+
+	websitecategorization "github.com/whois-api-llc/website-categorization-go"
+	"github.com/whois-api-llc/website-categorization-go/internal/cliutil"
+)
+
+func main() {
+	var (
+		clientFlags cliutil.ClientFlags
+
+		g            generator
+		input        string
+		output       string
+		failOnChange bool
+	)
+
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	clientFlags.RegisterAPI(fs)
+	fs.StringVar(&input, "input", "", "file with the saved /categories response, the API is requested if empty")
+	fs.StringVar(&output, "output", "categories_gen.go", "generated Go file")
+	fs.StringVar(&g.Package, "package", os.Getenv("GOPACKAGE"), "package name, defaults to the package running go generate")
+	fs.StringVar(&g.Type, "type", "CategoryID", "name of the generated type")
+	fs.StringVar(&g.Prefix, "prefix", "Category", "prefix of the generated constants")
+	fs.BoolVar(&failOnChange, "fail-on-change", false, "fail if categories are removed or renamed since the previous generation")
+	_ = fs.Parse(os.Args[1:])
+
+	log.SetFlags(0)
+	log.SetPrefix("wcategorization-categorygen: ")
+
+	if g.Package == "" {
+		g.Package = "main"
+	}
+
+	for _, ident := range []string{g.Package, g.Type, g.Prefix} {
+		if !token.IsIdentifier(ident) {
+			log.Fatalf("%q is not a valid identifier", ident)
+		}
+	}
+
+	categories, err := readCategories(input, &clientFlags)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	g.Source = input
+
+	constants := g.constants(categories)
+
+	if src, err := os.ReadFile(output); err == nil {
+		previous, err := g.parse(src)
+		if err != nil {
+			log.Fatalf("cannot parse %s: %v", output, err)
+		}
+
+		changes := compare(previous, constants)
+		for _, c := range changes {
+			log.Print(c)
+		}
+
+		if failOnChange && len(changes) > 0 {
+			log.Fatalf("%d categories changed, %s is not updated", len(changes), output)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		log.Fatal(err)
+	}
+
+	src, err := g.generate(constants)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err = os.WriteFile(output, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// readCategories returns the categories of the file or requests them from the API.
+func readCategories(path string, clientFlags *cliutil.ClientFlags) ([]websitecategorization.CategoryItem, error) {
+	if path == "" {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		clientFlags.NoCache = true
+
+		client, err := clientFlags.NewClient(ctx, log.Default(), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		categories, _, err := client.GetAllCategories(ctx)
+
+		return categories, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var categories []websitecategorization.CategoryItem
+	if err = json.Unmarshal(data, &categories); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+
+	return categories, nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+)
+
+// DNS message constants used by the filter, see RFC 1035.
+const (
+	headerLen = 12
+
+	typeA    = 1
+	typeAAAA = 28
+	classIN  = 1
+
+	rcodeSuccess  = 0
+	rcodeServFail = 2
+	rcodeNXDomain = 3
+
+	flagQR = 0x80
+	flagRD = 0x01
+	flagRA = 0x80
+
+	maxPointers = 16
+)
+
+var errMalformed = errors.New("malformed DNS message")
+
+// question is the first question of a DNS query.
+type question struct {
+	// Name is the queried name in lower case without the trailing dot.
+	Name  string
+	Type  uint16
+	Class uint16
+
+	// end is the offset of the first byte after the question section.
+	end int
+}
+
+// parseQuery parses the header and the first question of the DNS query.
+func parseQuery(msg []byte) (question, error) {
+	var q question
+
+	if len(msg) < headerLen {
+		return q, errMalformed
+	}
+
+	if msg[2]&flagQR != 0 || binary.BigEndian.Uint16(msg[4:6]) == 0 {
+		return q, errMalformed
+	}
+
+	name, offset, err := readName(msg, headerLen)
+	if err != nil {
+		return q, err
+	}
+
+	if offset+4 > len(msg) {
+		return q, errMalformed
+	}
+
+	q.Name = name
+	q.Type = binary.BigEndian.Uint16(msg[offset:])
+	q.Class = binary.BigEndian.Uint16(msg[offset+2:])
+	q.end = offset + 4
+
+	return q, nil
+}
+
+// readName reads the domain name starting at the offset and returns the offset following it.
+func readName(msg []byte, offset int) (string, int, error) {
+	var labels []string
+
+	end := -1
+
+	for pointers := 0; ; {
+		if offset >= len(msg) {
+			return "", 0, errMalformed
+		}
+
+		length := int(msg[offset])
+
+		switch {
+		case length == 0:
+			if end < 0 {
+				end = offset + 1
+			}
+
+			return strings.ToLower(strings.Join(labels, ".")), end, nil
+		case length&0xC0 == 0xC0:
+			if offset+2 > len(msg) {
+				return "", 0, errMalformed
+			}
+
+			if end < 0 {
+				end = offset + 2
+			}
+
+			pointers++
+			if pointers > maxPointers {
+				return "", 0, errMalformed
+			}
+
+			offset = int(binary.BigEndian.Uint16(msg[offset:]) & 0x3FFF)
+		case length&0xC0 != 0:
+			return "", 0, errMalformed
+		default:
+			if offset+1+length > len(msg) {
+				return "", 0, errMalformed
+			}
+
+			labels = append(labels, string(msg[offset+1:offset+1+length]))
+			offset += 1 + length
+		}
+	}
+}
+
+// newReply creates the reply to the query with the specified response code and answer records.
+// The reply contains the header and the question of the query only, extra sections are dropped.
+func newReply(query []byte, q question, rcode byte, answers ...[]byte) []byte {
+	reply := make([]byte, q.end, q.end+len(answers)*32)
+	copy(reply, query[:q.end])
+
+	reply[2] = flagQR | query[2]&0x78 | query[2]&flagRD
+	reply[3] = flagRA | rcode
+	binary.BigEndian.PutUint16(reply[4:], 1)
+	binary.BigEndian.PutUint16(reply[6:], uint16(len(answers)))
+	binary.BigEndian.PutUint16(reply[8:], 0)
+	binary.BigEndian.PutUint16(reply[10:], 0)
+
+	for _, answer := range answers {
+		reply = append(reply, answer...)
+	}
+
+	return reply
+}
+
+// answerRecord creates the resource record for the queried name.
+// The name is encoded as the pointer to the question.
+func answerRecord(rrType uint16, ttl uint32, data []byte) []byte {
+	record := make([]byte, 12, 12+len(data))
+	binary.BigEndian.PutUint16(record[0:], 0xC000|headerLen)
+	binary.BigEndian.PutUint16(record[2:], rrType)
+	binary.BigEndian.PutUint16(record[4:], classIN)
+	binary.BigEndian.PutUint32(record[6:], ttl)
+	binary.BigEndian.PutUint16(record[10:], uint16(len(data)))
+
+	return append(record, data...)
+}
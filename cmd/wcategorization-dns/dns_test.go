@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// newQuery creates the DNS query for the name with the recursion desired flag.
+func newQuery(id uint16, name string, qtype uint16) []byte {
+	msg := make([]byte, headerLen, headerLen+len(name)+6)
+	binary.BigEndian.PutUint16(msg[0:], id)
+	msg[2] = flagRD
+	binary.BigEndian.PutUint16(msg[4:], 1)
+
+	for _, label := range strings.Split(name, ".") {
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+
+	msg = append(msg, 0, 0, 0, 0, 0)
+	binary.BigEndian.PutUint16(msg[len(msg)-4:], qtype)
+	binary.BigEndian.PutUint16(msg[len(msg)-2:], classIN)
+
+	return msg
+}
+
+// TestParseQuery tests the parseQuery function.
+func TestParseQuery(t *testing.T) {
+	compressed := newQuery(1, "example.com", typeA)
+	// Replace the name with the pointer to the name appended after the question.
+	compressed = append(compressed[:headerLen], 0xC0, byte(headerLen+6))
+	compressed = append(compressed, 0, typeA, 0, classIN)
+	compressed = append(compressed, 3, 'W', 'W', 'W', 0)
+
+	tests := []struct {
+		name    string
+		msg     []byte
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "plain name",
+			msg:  newQuery(1, "WWW.Example.com", typeAAAA),
+			want: "www.example.com",
+		},
+		{
+			name: "compressed name",
+			msg:  compressed,
+			want: "www",
+		},
+		{
+			name:    "truncated",
+			msg:     newQuery(1, "example.com", typeA)[:20],
+			wantErr: true,
+		},
+		{
+			name:    "reply",
+			msg:     newReply(newQuery(1, "example.com", typeA), question{end: 29}, rcodeSuccess),
+			wantErr: true,
+		},
+		{
+			name:    "pointer loop",
+			msg:     append(newQuery(1, "a", typeA)[:headerLen], 0xC0, headerLen, 0, 1, 0, 1),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseQuery(tt.msg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got.Name != tt.want {
+				t.Errorf("parseQuery() name = %q, want %q", got.Name, tt.want)
+			}
+		})
+	}
+}
+
+// TestNewReply tests the newReply function.
+func TestNewReply(t *testing.T) {
+	query := newQuery(0xABCD, "example.com", typeA)
+
+	q, err := parseQuery(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reply := newReply(query, q, rcodeSuccess, answerRecord(typeA, 60, []byte{192, 0, 2, 1}))
+
+	if binary.BigEndian.Uint16(reply) != 0xABCD {
+		t.Error("reply ID does not match the query")
+	}
+
+	if reply[2]&flagQR == 0 || reply[2]&flagRD == 0 || reply[3] != flagRA {
+		t.Errorf("reply flags = %08b %08b", reply[2], reply[3])
+	}
+
+	if binary.BigEndian.Uint16(reply[6:]) != 1 {
+		t.Error("reply must contain one answer")
+	}
+
+	if got := reply[len(reply)-4:]; string(got) != string([]byte{192, 0, 2, 1}) {
+		t.Errorf("reply address = %v", got)
+	}
+}
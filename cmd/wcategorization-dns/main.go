@@ -0,0 +1,104 @@
+// Command wcategorization-dns is the DNS forwarder that filters names by website category.
+//
+// Usage:
+//
+//	wcategorization-dns -listen :53 -upstream 1.1.1.1:53 -deny 10,11 -sinkhole 0.0.0.0,::
+//
+// Queries for allowed names are forwarded to the upstream resolver as is. Queries for blocked
+// names are answered with the sinkhole addresses, or with NXDOMAIN if no sinkhole is set.
+// Categorization results are cached, so repeated queries do not cause API requests.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/whois-api-llc/website-categorization-go/internal/cliutil"
+)
+
+func main() {
+	var (
+		clientFlags  cliutil.ClientFlags
+		webhookFlags cliutil.WebhookFlags
+		policyFlags  cliutil.PolicyFlags
+
+		listen   string
+		sinkhole string
+		ttl      uint
+	)
+
+	r := &resolver{
+		logger: log.New(os.Stderr, "wcategorization-dns: ", log.LstdFlags),
+	}
+
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	clientFlags.Register(fs)
+	webhookFlags.Register(fs)
+	policyFlags.Register(fs)
+	fs.StringVar(&listen, "listen", ":53", "address to listen on over UDP and TCP")
+	fs.StringVar(&r.upstream, "upstream", "1.1.1.1:53", "upstream resolver address")
+	fs.StringVar(&sinkhole, "sinkhole", "", "comma-separated IPv4 and IPv6 addresses returned for blocked names, "+
+		"NXDOMAIN is returned if empty")
+	fs.UintVar(&ttl, "ttl", 300, "TTL of the sinkhole records")
+	fs.BoolVar(&r.failOpen, "fail-open", false, "resolve names that cannot be categorized")
+	fs.DurationVar(&r.timeout, "timeout", 5*time.Second, "timeout of a single query")
+	_ = fs.Parse(os.Args[1:])
+
+	r.ttl = uint32(ttl)
+
+	for _, field := range strings.Split(sinkhole, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		ip := net.ParseIP(field)
+
+		switch {
+		case ip == nil:
+			r.logger.Fatalf("invalid sinkhole address %q", field)
+		case ip.To4() != nil:
+			r.sinkhole4 = ip
+		default:
+			r.sinkhole6 = ip
+		}
+	}
+
+	ctx := context.Background()
+
+	var err error
+
+	if r.notifier, err = webhookFlags.NewNotifier(ctx, r.logger); err != nil {
+		r.logger.Fatal(err)
+	}
+
+	if r.client, err = clientFlags.NewClient(ctx, r.logger, r.notifier); err != nil {
+		r.logger.Fatal(err)
+	}
+
+	if r.policy, err = policyFlags.Policy(); err != nil {
+		r.logger.Fatal(err)
+	}
+
+	packetConn, err := net.ListenPacket("udp", listen)
+	if err != nil {
+		r.logger.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp", listen)
+	if err != nil {
+		r.logger.Fatal(err)
+	}
+
+	go func() {
+		r.logger.Fatal(r.serveTCP(listener))
+	}()
+
+	r.logger.Printf("listening on %s", listen)
+	r.logger.Fatal(r.serveUDP(packetConn))
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	websitecategorization "github.com/whois-api-llc/website-categorization-go"
+	"github.com/whois-api-llc/website-categorization-go/internal/cliutil"
+)
+
+// maxMessageLen is the maximum length of a DNS message.
+const maxMessageLen = 65535
+
+// resolver is the DNS forwarder that filters names according to their website category.
+type resolver struct {
+	client *websitecategorization.Client
+	policy websitecategorization.Policy
+
+	// upstream is the address of the resolver the allowed queries are forwarded to.
+	upstream string
+
+	// sinkhole4 and sinkhole6 are returned for blocked A and AAAA queries.
+	// Blocked names are answered with NXDOMAIN if both are nil.
+	sinkhole4 net.IP
+	sinkhole6 net.IP
+	ttl       uint32
+
+	failOpen bool
+	timeout  time.Duration
+	logger   *log.Logger
+
+	// notifier receives the policy violations if not nil.
+	notifier *cliutil.Notifier
+}
+
+// resolve returns the reply to the query received over the network ("udp" or "tcp").
+func (r *resolver) resolve(ctx context.Context, query []byte, network string) ([]byte, error) {
+	q, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	if filtered(q) && r.blocked(ctx, q.Name) {
+		return r.blockReply(query, q), nil
+	}
+
+	reply, err := r.forward(ctx, query, network)
+	if err != nil {
+		r.logger.Printf("cannot forward query for %s: %v", q.Name, err)
+
+		return newReply(query, q, rcodeServFail), nil
+	}
+
+	return reply, nil
+}
+
+// filtered reports whether the queried name is subject to categorization.
+// Reverse lookups and single-label names are never categorized.
+func filtered(q question) bool {
+	return q.Class == classIN && strings.Contains(q.Name, ".") && !strings.HasSuffix(q.Name, ".arpa")
+}
+
+// blocked reports whether the policy denies the name.
+func (r *resolver) blocked(ctx context.Context, name string) bool {
+	wCategorizationResp, _, err := r.client.Get(ctx, name)
+	if err != nil {
+		r.logger.Printf("categorization of %s failed: %v", name, err)
+
+		return !r.failOpen
+	}
+
+	decision := r.policy.Decide(wCategorizationResp)
+	if decision.Blocked() {
+		r.logger.Printf("blocked %s", name)
+		r.notifier.Notify(websitecategorization.PolicyViolationEvent(wCategorizationResp, decision))
+	}
+
+	return decision.Blocked()
+}
+
+// blockReply returns the reply to the blocked query.
+func (r *resolver) blockReply(query []byte, q question) []byte {
+	switch {
+	case q.Type == typeA && r.sinkhole4 != nil:
+		return newReply(query, q, rcodeSuccess, answerRecord(typeA, r.ttl, r.sinkhole4.To4()))
+	case q.Type == typeAAAA && r.sinkhole6 != nil:
+		return newReply(query, q, rcodeSuccess, answerRecord(typeAAAA, r.ttl, r.sinkhole6.To16()))
+	case r.sinkhole4 != nil || r.sinkhole6 != nil:
+		return newReply(query, q, rcodeSuccess)
+	}
+
+	return newReply(query, q, rcodeNXDomain)
+}
+
+// forward sends the query to the upstream resolver using the same network it was received over.
+func (r *resolver) forward(ctx context.Context, query []byte, network string) ([]byte, error) {
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, network, r.upstream)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err = conn.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+
+	var reply []byte
+
+	if network == "tcp" {
+		if err = writeTCPMessage(conn, query); err != nil {
+			return nil, err
+		}
+
+		reply, err = readTCPMessage(conn)
+	} else {
+		if _, err = conn.Write(query); err != nil {
+			return nil, err
+		}
+
+		buf := make([]byte, maxMessageLen)
+
+		var n int
+
+		n, err = conn.Read(buf)
+		reply = buf[:n]
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(reply) < headerLen || reply[0] != query[0] || reply[1] != query[1] {
+		return nil, errors.New("unexpected reply from upstream")
+	}
+
+	return reply, nil
+}
+
+// serveUDP serves the queries received over the packet connection until it is closed.
+func (r *resolver) serveUDP(conn net.PacketConn) error {
+	for {
+		buf := make([]byte, maxMessageLen)
+
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+
+		go func() {
+			reply, err := r.resolve(context.Background(), buf[:n], "udp")
+			if err != nil {
+				return
+			}
+
+			if _, err = conn.WriteTo(reply, addr); err != nil {
+				r.logger.Printf("cannot send reply to %s: %v", addr, err)
+			}
+		}()
+	}
+}
+
+// serveTCP serves the queries received over the accepted connections until the listener is closed.
+func (r *resolver) serveTCP(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+
+		go r.serveTCPConn(conn)
+	}
+}
+
+// serveTCPConn serves the queries received over the TCP connection.
+func (r *resolver) serveTCPConn(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(r.timeout)); err != nil {
+			return
+		}
+
+		query, err := readTCPMessage(conn)
+		if err != nil {
+			return
+		}
+
+		reply, err := r.resolve(context.Background(), query, "tcp")
+		if err != nil {
+			return
+		}
+
+		if err = writeTCPMessage(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+// readTCPMessage reads the length-prefixed DNS message.
+func readTCPMessage(conn io.Reader) ([]byte, error) {
+	var length [2]byte
+
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		return nil, err
+	}
+
+	msg := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(conn, msg); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// writeTCPMessage writes the length-prefixed DNS message.
+func writeTCPMessage(conn io.Writer, msg []byte) error {
+	buf := make([]byte, 2, 2+len(msg))
+	binary.BigEndian.PutUint16(buf, uint16(len(msg)))
+
+	_, err := conn.Write(append(buf, msg...))
+
+	return err
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"encoding/binary"
+	"io"
+	"log"
+	"net"
+	"testing"
+	"time"
+
+	websitecategorization "github.com/whois-api-llc/website-categorization-go"
+	"github.com/whois-api-llc/website-categorization-go/internal/apitest"
+)
+
+// upstreamAddress is the address returned by the fake upstream resolver.
+var upstreamAddress = []byte{192, 0, 2, 1}
+
+// fakeUpstream starts the resolver answering every query with upstreamAddress over UDP and TCP.
+func fakeUpstream(t *testing.T) string {
+	t.Helper()
+
+	answer := func(query []byte) []byte {
+		q, err := parseQuery(query)
+		if err != nil {
+			return nil
+		}
+
+		return newReply(query, q, rcodeSuccess, answerRecord(typeA, 60, upstreamAddress))
+	}
+
+	packetConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp", packetConn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = packetConn.Close()
+		_ = listener.Close()
+	})
+
+	go func() {
+		buf := make([]byte, maxMessageLen)
+
+		for {
+			n, addr, err := packetConn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			_, _ = packetConn.WriteTo(answer(buf[:n]), addr)
+		}
+	}()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			if query, err := readTCPMessage(conn); err == nil {
+				_ = writeTCPMessage(conn, answer(query))
+			}
+
+			_ = conn.Close()
+		}
+	}()
+
+	return packetConn.LocalAddr().String()
+}
+
+// startResolver starts the filtering resolver over UDP and TCP and returns its address.
+func startResolver(t *testing.T, r *resolver) string {
+	t.Helper()
+
+	packetConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp", packetConn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = packetConn.Close()
+		_ = listener.Close()
+	})
+
+	go func() { _ = r.serveUDP(packetConn) }()
+	go func() { _ = r.serveTCP(listener) }()
+
+	return packetConn.LocalAddr().String()
+}
+
+// exchange sends the query to the resolver and returns the reply.
+func exchange(t *testing.T, network, address string, query []byte) []byte {
+	t.Helper()
+
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if network == "tcp" {
+		if err = writeTCPMessage(conn, query); err != nil {
+			t.Fatal(err)
+		}
+
+		reply, err := readTCPMessage(conn)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return reply
+	}
+
+	if _, err = conn.Write(query); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, maxMessageLen)
+
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return buf[:n]
+}
+
+// TestResolver tests filtering of DNS queries.
+func TestResolver(t *testing.T) {
+	api := apitest.NewServer(t, map[string][]websitecategorization.Category{
+		"allowed.test": {{ID: 5, Name: "Computer and Internet Info", Confidence: 0.9}},
+		"blocked.test": {{ID: 10, Name: "Gambling", Confidence: 0.8}},
+	})
+	client := api.NewClient(t, "at_test")
+	upstream := fakeUpstream(t)
+
+	nxdomain := startResolver(t, &resolver{
+		client:   client,
+		policy:   websitecategorization.Policy{Deny: []int{10}},
+		upstream: upstream,
+		timeout:  5 * time.Second,
+		logger:   log.New(io.Discard, "", 0),
+	})
+
+	sinkhole := startResolver(t, &resolver{
+		client:    client,
+		policy:    websitecategorization.Policy{Deny: []int{10}},
+		upstream:  upstream,
+		sinkhole4: net.IPv4(0, 0, 0, 0),
+		ttl:       60,
+		timeout:   5 * time.Second,
+		logger:    log.New(io.Discard, "", 0),
+	})
+
+	tests := []struct {
+		name      string
+		network   string
+		address   string
+		domain    string
+		qtype     uint16
+		wantCode  byte
+		wantAddrs []byte
+	}{
+		{
+			name:      "allowed over UDP",
+			network:   "udp",
+			address:   nxdomain,
+			domain:    "allowed.test",
+			qtype:     typeA,
+			wantCode:  rcodeSuccess,
+			wantAddrs: upstreamAddress,
+		},
+		{
+			name:      "allowed over TCP",
+			network:   "tcp",
+			address:   nxdomain,
+			domain:    "allowed.test",
+			qtype:     typeA,
+			wantCode:  rcodeSuccess,
+			wantAddrs: upstreamAddress,
+		},
+		{
+			name:     "blocked with NXDOMAIN",
+			network:  "udp",
+			address:  nxdomain,
+			domain:   "blocked.test",
+			qtype:    typeA,
+			wantCode: rcodeNXDomain,
+		},
+		{
+			name:      "blocked with sinkhole",
+			network:   "tcp",
+			address:   sinkhole,
+			domain:    "blocked.test",
+			qtype:     typeA,
+			wantCode:  rcodeSuccess,
+			wantAddrs: []byte{0, 0, 0, 0},
+		},
+		{
+			name:     "blocked with sinkhole without IPv6 address",
+			network:  "udp",
+			address:  sinkhole,
+			domain:   "blocked.test",
+			qtype:    typeAAAA,
+			wantCode: rcodeSuccess,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reply := exchange(t, tt.network, tt.address, newQuery(42, tt.domain, tt.qtype))
+
+			if binary.BigEndian.Uint16(reply) != 42 {
+				t.Fatal("reply ID does not match the query")
+			}
+
+			if got := reply[3] & 0x0F; got != tt.wantCode {
+				t.Errorf("rcode = %d, want %d", got, tt.wantCode)
+			}
+
+			answers := binary.BigEndian.Uint16(reply[6:])
+
+			switch {
+			case tt.wantAddrs == nil && answers != 0:
+				t.Errorf("answers = %d, want none", answers)
+			case tt.wantAddrs != nil && (answers != 1 || string(reply[len(reply)-4:]) != string(tt.wantAddrs)):
+				t.Errorf("answer = %v, want %v", reply[len(reply)-4:], tt.wantAddrs)
+			}
+		})
+	}
+
+	if got := api.Requests(); got != 2 {
+		t.Errorf("API requests = %d, want 2 as repeated names must be served from the cache", got)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"time"
+
+	"github.com/whois-api-llc/website-categorization-go/internal/lru"
+)
+
+// cachedResponse is the upstream response stored in the cache.
+type cachedResponse struct {
+	contentType string
+	body        []byte
+}
+
+// responseCache is the in-memory LRU cache of upstream responses with the limited entry lifetime.
+type responseCache struct {
+	lru *lru.Cache
+}
+
+// newResponseCache creates responseCache. Zero ttl means that entries never expire,
+// zero maxEntries means that the number of entries is not limited.
+func newResponseCache(ttl time.Duration, maxEntries int) *responseCache {
+	return &responseCache{lru: lru.New(ttl, maxEntries)}
+}
+
+// get returns the response stored under the key if it has not expired yet.
+func (c *responseCache) get(key string) (cachedResponse, bool) {
+	value, ok := c.lru.Get(key)
+	if !ok {
+		return cachedResponse{}, false
+	}
+
+	return value.(cachedResponse), true
+}
+
+// set stores the response under the key.
+func (c *responseCache) set(key string, response cachedResponse) {
+	c.lru.Set(key, response)
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	websitecategorization "github.com/whois-api-llc/website-categorization-go"
+)
+
+const (
+	// cacheHeader reports whether the response is served from the cache.
+	cacheHeader = "X-Cache"
+
+	// tokenParam is the query parameter holding the caller token, the same one the API expects the API key in.
+	tokenParam = "apiKey"
+)
+
+// gateway is the HTTP server exposing the Website Categorization API endpoints. It serves responses from
+// the shared cache and forwards cache misses to the API with the server-side API key.
+type gateway struct {
+	service websitecategorization.WCategorizationService
+
+	// basePath is the path of the domain endpoint, the categories endpoint is basePath + "/categories".
+	basePath string
+
+	cache *responseCache
+
+	// callers authenticates requests. Nil means that the gateway accepts anonymous requests.
+	callers *callers
+
+	// accessLog receives a line per request. Caller tokens are never logged.
+	accessLog *log.Logger
+}
+
+// newGateway creates gateway.
+func newGateway(
+	service websitecategorization.WCategorizationService,
+	basePath string,
+	cache *responseCache,
+	callers *callers,
+	accessLog *log.Logger,
+) *gateway {
+	return &gateway{
+		service:   service,
+		basePath:  "/" + strings.Trim(basePath, "/"),
+		cache:     cache,
+		callers:   callers,
+		accessLog: accessLog,
+	}
+}
+
+// statusRecorder remembers the status code and the size of the response for the access log.
+type statusRecorder struct {
+	http.ResponseWriter
+
+	status int
+	size   int
+}
+
+// WriteHeader records the status code.
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the response size.
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+
+	n, err := r.ResponseWriter.Write(b)
+	r.size += n
+
+	return n, err
+}
+
+// ServeHTTP serves the API request.
+func (g *gateway) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	started := time.Now()
+	rec := &statusRecorder{ResponseWriter: w}
+	name := "-"
+
+	defer func() {
+		g.accessLog.Printf("%s %s %q %d %d %s %s", req.RemoteAddr, name, req.Method+" "+redactedURI(req.URL),
+			rec.status, rec.size, valueOr(rec.Header().Get(cacheHeader), "-"), time.Since(started).Round(time.Millisecond))
+	}()
+
+	var endpoint websitecategorization.Endpoint
+
+	switch strings.TrimRight(req.URL.Path, "/") {
+	case strings.TrimRight(g.basePath, "/"):
+		endpoint = websitecategorization.EndpointDomain
+	case strings.TrimRight(g.basePath, "/") + "/categories":
+		endpoint = websitecategorization.EndpointCategories
+	default:
+		writeError(rec, http.StatusNotFound, "Not found")
+
+		return
+	}
+
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		rec.Header().Set("Allow", "GET, HEAD")
+		writeError(rec, http.StatusMethodNotAllowed, "Method not allowed")
+
+		return
+	}
+
+	query := req.URL.Query()
+
+	var c caller
+
+	if g.callers != nil {
+		var ok bool
+
+		c, ok = g.callers.authenticate(query.Get(tokenParam))
+		if !ok {
+			writeError(rec, http.StatusUnauthorized, "Access restricted. Check the API key.")
+
+			return
+		}
+
+		name = c.Name
+	}
+
+	opts, err := queryOptions(endpoint, query)
+	if err != nil {
+		writeError(rec, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	domainName := query.Get("domainName")
+	if endpoint == websitecategorization.EndpointDomain && domainName == "" {
+		writeError(rec, http.StatusBadRequest, `invalid argument: "domainName" can not be empty`)
+
+		return
+	}
+
+	key := requestKey(endpoint, domainName, opts)
+
+	if cached, ok := g.cache.get(key); ok {
+		writeResponse(rec, "HIT", cached)
+
+		return
+	}
+
+	// Only domain lookups are billable, so the categories list does not count against quotas.
+	if g.callers != nil && endpoint == websitecategorization.EndpointDomain && !g.callers.take(c) {
+		writeError(rec, http.StatusTooManyRequests, "Daily quota of "+strconv.Itoa(c.DailyQuota)+" requests exceeded")
+
+		return
+	}
+
+	resp, err := g.forward(req.Context(), endpoint, domainName, opts)
+	if err != nil {
+		// Requests that have not reached the API do not count against the quota, API errors may be billed.
+		if g.callers != nil && endpoint == websitecategorization.EndpointDomain && resp == nil {
+			g.callers.refund(c)
+		}
+
+		g.writeFailure(rec, resp, err)
+
+		return
+	}
+
+	cached := cachedResponse{contentType: resp.Header.Get("Content-Type"), body: resp.Body}
+
+	// The API reports some errors with the status code 200, they are relayed but never cached.
+	if !apiError(resp.Body) {
+		g.cache.set(key, cached)
+	}
+
+	writeResponse(rec, "MISS", cached)
+}
+
+// apiError reports whether the body is the JSON encoded API error message.
+func apiError(body []byte) bool {
+	var errMsg websitecategorization.ErrorMessage
+
+	return json.Unmarshal(body, &errMsg) == nil && (errMsg.Code != 0 || errMsg.Message != "")
+}
+
+// forward requests the API endpoint.
+func (g *gateway) forward(
+	ctx context.Context,
+	endpoint websitecategorization.Endpoint,
+	domainName string,
+	opts []websitecategorization.Option,
+) (*websitecategorization.Response, error) {
+	if endpoint == websitecategorization.EndpointCategories {
+		return g.service.GetAllCategoriesRaw(ctx, opts...)
+	}
+
+	return g.service.GetRaw(ctx, domainName, opts...)
+}
+
+// writeFailure relays API error responses as is and reports other errors in the API error format.
+func (g *gateway) writeFailure(w http.ResponseWriter, resp *websitecategorization.Response, err error) {
+	var (
+		errResp *websitecategorization.ErrorResponse
+		argErr  *websitecategorization.ArgError
+	)
+
+	switch {
+	case errors.As(err, &errResp) && resp != nil:
+		w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+		w.Header().Set(cacheHeader, "MISS")
+		w.WriteHeader(resp.StatusCode)
+		_, _ = w.Write(resp.Body)
+	case errors.As(err, &argErr):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, websitecategorization.ErrBudgetExceeded):
+		writeError(w, http.StatusTooManyRequests, err.Error())
+	case errors.Is(err, websitecategorization.ErrAPIKeysExhausted):
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+	default:
+		writeError(w, http.StatusBadGateway, "Upstream request failed")
+	}
+}
+
+// queryOptions converts the API query parameters to the options of the endpoint.
+// The unknown parameters and the ones not applicable to the endpoint are ignored as the API does.
+func queryOptions(endpoint websitecategorization.Endpoint, query url.Values) ([]websitecategorization.Option, error) {
+	var opts []websitecategorization.Option
+
+	if value := query.Get("outputFormat"); value != "" {
+		opts = append(opts, websitecategorization.OptionOutputFormat(websitecategorization.OutputFormat(value)))
+	}
+
+	if value := query.Get("order"); value != "" {
+		opts = append(opts, websitecategorization.OptionOrder(websitecategorization.Order(value)))
+	}
+
+	if value := query.Get("minConfidence"); value != "" {
+		minConfidence, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, &websitecategorization.ArgError{Name: "minConfidence", Message: "must be a number"}
+		}
+
+		opts = append(opts, websitecategorization.OptionMinConfidence(minConfidence))
+	}
+
+	if err := websitecategorization.ValidateOptions(opts...); err != nil {
+		return nil, err
+	}
+
+	applicable := opts[:0]
+
+	for _, opt := range opts {
+		if opt.AppliesTo(endpoint) {
+			applicable = append(applicable, opt)
+		}
+	}
+
+	return applicable, nil
+}
+
+// requestKey returns the cache key of the request. The caller token is not a part of the key,
+// so all callers share the cache.
+func requestKey(endpoint websitecategorization.Endpoint, domainName string, opts []websitecategorization.Option) string {
+	key := url.Values{}
+
+	for _, opt := range opts {
+		opt.Apply(key)
+	}
+
+	if endpoint == websitecategorization.EndpointDomain {
+		key.Set("domainName", strings.TrimSuffix(strings.ToLower(domainName), "."))
+	}
+
+	return endpoint.String() + "?" + key.Encode()
+}
+
+// writeResponse writes the API response.
+func writeResponse(w http.ResponseWriter, cacheStatus string, resp cachedResponse) {
+	if resp.contentType != "" {
+		w.Header().Set("Content-Type", resp.contentType)
+	}
+
+	w.Header().Set(cacheHeader, cacheStatus)
+	w.Header().Set("Content-Length", strconv.Itoa(len(resp.body)))
+	_, _ = w.Write(resp.body)
+}
+
+// writeError writes the error in the API error format.
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(websitecategorization.ErrorMessage{Code: status, Message: message})
+}
+
+// redactedURI returns the request URI with the caller token replaced.
+func redactedURI(u *url.URL) string {
+	query := u.Query()
+	if _, ok := query[tokenParam]; !ok {
+		return u.RequestURI()
+	}
+
+	query.Set(tokenParam, "REDACTED")
+
+	redacted := *u
+	redacted.RawQuery = query.Encode()
+
+	return redacted.RequestURI()
+}
+
+// valueOr returns the value or the fallback if the value is empty.
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+
+	return value
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	websitecategorization "github.com/whois-api-llc/website-categorization-go"
+	"github.com/whois-api-llc/website-categorization-go/internal/apitest"
+)
+
+const serverKey = "at_server_key"
+
+// gatewayCategories are the categories returned by the fake API server.
+var gatewayCategories = map[string][]websitecategorization.Category{
+	"example.com": {{ID: 1, Name: "Arts", Confidence: 0.9}, {ID: 2, Name: "Games", Confidence: 0.5}},
+}
+
+// fakeAPI starts the fake API server that fails the requests made without the server-side API key.
+func fakeAPI(t *testing.T) *apitest.Server {
+	t.Helper()
+
+	api := apitest.NewServer(t, gatewayCategories)
+	api.RequireAPIKey(serverKey)
+
+	api.Handle("broken.test", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = io.WriteString(w, `{"code":422,"messages":"Invalid domain"}`)
+	})
+	api.Handle("busy.test", func(w http.ResponseWriter, req *http.Request) {
+		_, _ = io.WriteString(w, `{"code":499,"messages":"Could not process the request"}`)
+	})
+	api.Handle("down.test", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = io.WriteString(w, `<html><body>502 Bad Gateway</body></html>`)
+	})
+
+	return api
+}
+
+// newTestGateway starts the gateway forwarding requests to the API server.
+func newTestGateway(t *testing.T, api *apitest.Server, registry *callers, accessLog io.Writer) *httptest.Server {
+	t.Helper()
+
+	client := websitecategorization.NewClient(serverKey, websitecategorization.ClientParams{
+		HTTPClient:             api.Client(),
+		WCategorizationBaseURL: api.BaseURL(t, "/api/v3"),
+	})
+
+	return httptest.NewServer(newGateway(client, "/api/v3/", newResponseCache(time.Hour, 0), registry,
+		log.New(accessLog, "", 0)))
+}
+
+// get requests the gateway and returns the response with its body.
+func get(t *testing.T, gw *httptest.Server, uri string) (*http.Response, string) {
+	t.Helper()
+
+	resp, err := gw.Client().Get(gw.URL + uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	return resp, string(body)
+}
+
+// TestGateway tests forwarding, caching and error handling of the gateway.
+func TestGateway(t *testing.T) {
+	api := fakeAPI(t)
+
+	registry, err := newCallers([]caller{
+		{Name: "reports", Token: "token-a"},
+		{Name: "crawler", Token: "token-b"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gw := newTestGateway(t, api, registry, io.Discard)
+	defer gw.Close()
+
+	tests := []struct {
+		name         string
+		uri          string
+		wantCode     int
+		wantCache    string
+		wantBody     string
+		wantRequests int32
+	}{
+		{
+			name:         "miss",
+			uri:          "/api/v3?apiKey=token-a&domainName=example.com&minConfidence=0.6",
+			wantCode:     http.StatusOK,
+			wantCache:    "MISS",
+			wantBody:     `"categories":[{"confidence":0.9,"id":1,"name":"Arts"}]`,
+			wantRequests: 1,
+		},
+		{
+			name:         "hit shared by callers",
+			uri:          "/api/v3/?apiKey=token-b&domainName=Example.com.&minConfidence=0.60",
+			wantCode:     http.StatusOK,
+			wantCache:    "HIT",
+			wantBody:     `"categories":[{"confidence":0.9,"id":1,"name":"Arts"}]`,
+			wantRequests: 0,
+		},
+		{
+			name:         "other options",
+			uri:          "/api/v3?apiKey=token-b&domainName=example.com",
+			wantCode:     http.StatusOK,
+			wantCache:    "MISS",
+			wantBody:     `{"confidence":0.5,"id":2,"name":"Games"}`,
+			wantRequests: 1,
+		},
+		{
+			name:         "categories",
+			uri:          "/api/v3/categories?apiKey=token-a&order=abc",
+			wantCode:     http.StatusOK,
+			wantCache:    "MISS",
+			wantBody:     `[{"id":1,"name":"Arts"},{"id":2,"name":"Games"}]`,
+			wantRequests: 1,
+		},
+		{
+			name:         "API error",
+			uri:          "/api/v3?apiKey=token-a&domainName=broken.test",
+			wantCode:     http.StatusUnprocessableEntity,
+			wantBody:     `{"code":422,"messages":"Invalid domain"}`,
+			wantRequests: 1,
+		},
+		{
+			name:         "API error with status 200",
+			uri:          "/api/v3?apiKey=token-a&domainName=busy.test",
+			wantCode:     http.StatusOK,
+			wantCache:    "MISS",
+			wantBody:     `{"code":499,"messages":"Could not process the request"}`,
+			wantRequests: 1,
+		},
+		{
+			name:         "API error with status 200 is not cached",
+			uri:          "/api/v3?apiKey=token-b&domainName=busy.test",
+			wantCode:     http.StatusOK,
+			wantCache:    "MISS",
+			wantBody:     `{"code":499,"messages":"Could not process the request"}`,
+			wantRequests: 1,
+		},
+		{
+			name:     "unknown token",
+			uri:      "/api/v3?apiKey=" + serverKey + "&domainName=example.com",
+			wantCode: http.StatusUnauthorized,
+			wantBody: `"code":401`,
+		},
+		{
+			name:     "invalid option",
+			uri:      "/api/v3?apiKey=token-a&domainName=example.com&minConfidence=2",
+			wantCode: http.StatusBadRequest,
+			wantBody: `minConfidence`,
+		},
+		{
+			name:     "no domain name",
+			uri:      "/api/v3?apiKey=token-a",
+			wantCode: http.StatusBadRequest,
+			wantBody: `domainName`,
+		},
+		{
+			name:     "unknown path",
+			uri:      "/api/v2?apiKey=token-a&domainName=example.com",
+			wantCode: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := api.Requests()
+
+			resp, body := get(t, gw, tt.uri)
+
+			if resp.StatusCode != tt.wantCode {
+				t.Errorf("status code = %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+
+			if tt.wantCache != "" && resp.Header.Get(cacheHeader) != tt.wantCache {
+				t.Errorf("%s = %q, want %q", cacheHeader, resp.Header.Get(cacheHeader), tt.wantCache)
+			}
+
+			if !strings.Contains(body, tt.wantBody) {
+				t.Errorf("body = %q, want it to contain %q", body, tt.wantBody)
+			}
+
+			if got := api.Requests() - before; got != tt.wantRequests {
+				t.Errorf("API requests = %d, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+// TestGatewayQuota tests that cache misses and API errors count against the caller quota and cache hits
+// and requests that have not reached the API do not.
+func TestGatewayQuota(t *testing.T) {
+	api := fakeAPI(t)
+
+	registry, err := newCallers([]caller{{Name: "reports", Token: "token-a", DailyQuota: 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gw := newTestGateway(t, api, registry, io.Discard)
+	defer gw.Close()
+
+	uris := []struct {
+		domainName string
+		wantCode   int
+	}{
+		{"a.com", http.StatusOK},
+		{"a.com", http.StatusOK},
+		{"down.test", http.StatusBadGateway},
+		{"b.com", http.StatusOK},
+		{"c.com", http.StatusTooManyRequests},
+		{"b.com", http.StatusOK},
+	}
+
+	for _, u := range uris {
+		resp, _ := get(t, gw, "/api/v3?apiKey=token-a&domainName="+u.domainName)
+		if resp.StatusCode != u.wantCode {
+			t.Errorf("%s: status code = %d, want %d", u.domainName, resp.StatusCode, u.wantCode)
+		}
+	}
+
+	if got := api.Requests(); got != 3 {
+		t.Errorf("API requests = %d, want 3", got)
+	}
+
+	resp, _ := get(t, gw, "/api/v3/categories?apiKey=token-a")
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("categories: status code = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	registry.now = func() time.Time { return time.Now().Add(24 * time.Hour) }
+
+	resp, _ = get(t, gw, "/api/v3?apiKey=token-a&domainName=c.com")
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("next day: status code = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	// Requests that have not reached the API are refunded.
+	api.Close()
+
+	for i := 0; i < 3; i++ {
+		if resp, _ = get(t, gw, "/api/v3?apiKey=token-a&domainName=d.com"); resp.StatusCode != http.StatusBadGateway {
+			t.Errorf("API is down: status code = %d, want %d", resp.StatusCode, http.StatusBadGateway)
+		}
+	}
+
+	if used := registry.used["reports"]; used != 1 {
+		t.Errorf("used quota = %d, want 1", used)
+	}
+}
+
+// TestGatewayAccessLog tests that the access log identifies callers without exposing their tokens.
+func TestGatewayAccessLog(t *testing.T) {
+	api := fakeAPI(t)
+
+	registry, err := newCallers([]caller{{Name: "reports", Token: "token-a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var accessLog bytes.Buffer
+
+	gw := newTestGateway(t, api, registry, &accessLog)
+	defer gw.Close()
+
+	get(t, gw, "/api/v3?apiKey=token-a&domainName=example.com")
+	get(t, gw, "/api/v3?apiKey=token-a&domainName=example.com")
+
+	lines := strings.Split(strings.TrimSpace(accessLog.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("access log has %d lines, want 2:\n%s", len(lines), accessLog.String())
+	}
+
+	for _, want := range []string{" reports ", " 200 ", "domainName=example.com", "REDACTED"} {
+		if !strings.Contains(lines[0], want) {
+			t.Errorf("access log line = %q, want it to contain %q", lines[0], want)
+		}
+	}
+
+	if !strings.Contains(lines[0], " MISS ") || !strings.Contains(lines[1], " HIT ") {
+		t.Errorf("access log does not report the cache status:\n%s", accessLog.String())
+	}
+
+	if strings.Contains(accessLog.String(), "token-a") {
+		t.Errorf("access log exposes the caller token:\n%s", accessLog.String())
+	}
+}
+
+// TestResponseCache tests expiration and eviction of cached responses.
+func TestResponseCache(t *testing.T) {
+	now := time.Now()
+
+	cache := newResponseCache(time.Minute, 2)
+	cache.lru.Now = func() time.Time { return now }
+
+	cache.set("a", cachedResponse{body: []byte("a")})
+	cache.set("b", cachedResponse{body: []byte("b")})
+
+	if _, ok := cache.get("a"); !ok {
+		t.Error("a is not cached")
+	}
+
+	cache.set("c", cachedResponse{body: []byte("c")})
+
+	if _, ok := cache.get("b"); ok {
+		t.Error("least recently used entry b is not evicted")
+	}
+
+	now = now.Add(time.Minute)
+
+	if _, ok := cache.get("a"); ok {
+		t.Error("expired entry a is returned")
+	}
+}
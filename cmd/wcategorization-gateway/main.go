@@ -0,0 +1,100 @@
+// Command wcategorization-gateway is the caching HTTP gateway that exposes the same endpoints and query
+// parameters as the Website Categorization API. Applications written in any language point their API base
+// URL at the gateway and share its cache, budget and server-side API key.
+//
+// Usage:
+//
+//	wcategorization-gateway -listen :8080 -callers callers.json -budget-daily 10000
+//
+// The callers file is the JSON list of callers, each passes its token as the apiKey parameter:
+//
+//	[{"name": "reports", "token": "s3cret", "dailyQuota": 1000}]
+//
+// The quota limits the number of domain lookups forwarded to the API per day, cached responses are not counted.
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/whois-api-llc/website-categorization-go/internal/cliutil"
+)
+
+func main() {
+	var (
+		clientFlags  cliutil.ClientFlags
+		webhookFlags cliutil.WebhookFlags
+
+		listen         string
+		basePath       string
+		callersPath    string
+		allowAnonymous bool
+		accessLogPath  string
+	)
+
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	clientFlags.Register(fs)
+	webhookFlags.Register(fs)
+	fs.StringVar(&listen, "listen", ":8080", "address to listen on")
+	fs.StringVar(&basePath, "base-path", "/api/v3", "path of the domain endpoint, the categories endpoint is under it")
+	fs.StringVar(&callersPath, "callers", "", "JSON file with caller tokens and quotas")
+	fs.BoolVar(&allowAnonymous, "allow-anonymous", false, "accept requests without a caller token when -callers is not set")
+	fs.StringVar(&accessLogPath, "access-log", "-", "file to append the access log to, - means stdout")
+	_ = fs.Parse(os.Args[1:])
+
+	logger := log.New(os.Stderr, "wcategorization-gateway: ", log.LstdFlags)
+
+	ctx := context.Background()
+
+	notifier, err := webhookFlags.NewNotifier(ctx, logger)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	// The gateway caches raw responses itself, GetRaw requests bypass the cache of the client.
+	clientFlags.NoCache = true
+
+	client, err := clientFlags.NewClient(ctx, logger, notifier)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	var registry *callers
+
+	switch {
+	case callersPath != "":
+		if registry, err = loadCallers(callersPath); err != nil {
+			logger.Fatal(err)
+		}
+	case !allowAnonymous:
+		logger.Fatal("either -callers or -allow-anonymous must be specified")
+	}
+
+	var accessLogOutput io.Writer = os.Stdout
+
+	if accessLogPath != "-" {
+		file, err := os.OpenFile(accessLogPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		defer file.Close()
+
+		accessLogOutput = file
+	}
+
+	server := &http.Server{
+		Addr: listen,
+		Handler: newGateway(client, basePath, newResponseCache(clientFlags.CacheTTL, clientFlags.CacheMax),
+			registry, log.New(accessLogOutput, "", log.LstdFlags)),
+		ReadHeaderTimeout: 30 * time.Second,
+		ErrorLog:          logger,
+	}
+
+	logger.Printf("listening on %s", listen)
+	logger.Fatal(server.ListenAndServe())
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// caller is the gateway user identified by the token.
+type caller struct {
+	// Token is the secret passed by the caller as the apiKey parameter.
+	Token string `json:"token"`
+
+	// Name identifies the caller in access logs.
+	Name string `json:"name"`
+
+	// DailyQuota is the maximum number of upstream requests per calendar day in UTC, zero means no limit.
+	// Requests served from the cache are not counted.
+	DailyQuota int `json:"dailyQuota"`
+}
+
+// callers authenticates callers and tracks their quotas. It is safe for concurrent use.
+type callers struct {
+	mu sync.Mutex
+
+	byToken map[string]caller
+	day     string
+	used    map[string]int
+
+	now func() time.Time
+}
+
+// loadCallers reads the JSON file with the list of callers.
+func loadCallers(path string) (*callers, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []caller
+	if err = json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("cannot parse callers: %w", err)
+	}
+
+	return newCallers(list)
+}
+
+// newCallers creates callers from the list.
+func newCallers(list []caller) (*callers, error) {
+	c := &callers{
+		byToken: make(map[string]caller, len(list)),
+		used:    make(map[string]int),
+		now:     time.Now,
+	}
+
+	for i, item := range list {
+		if item.Token == "" {
+			return nil, fmt.Errorf("caller #%d has no token", i+1)
+		}
+
+		if item.Name == "" {
+			item.Name = fmt.Sprintf("caller-%d", i+1)
+		}
+
+		c.byToken[item.Token] = item
+	}
+
+	return c, nil
+}
+
+// authenticate returns the caller with the token.
+func (c *callers) authenticate(token string) (caller, bool) {
+	if token == "" {
+		return caller{}, false
+	}
+
+	item, ok := c.byToken[token]
+
+	return item, ok
+}
+
+// take counts an upstream request of the caller. It returns false if the caller quota is spent.
+func (c *callers) take(item caller) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if day := c.now().UTC().Format("2006-01-02"); day != c.day {
+		c.day = day
+		c.used = make(map[string]int)
+	}
+
+	if item.DailyQuota > 0 && c.used[item.Name] >= item.DailyQuota {
+		return false
+	}
+
+	c.used[item.Name]++
+
+	return true
+}
+
+// refund gives back the request counted by take when the upstream request fails.
+func (c *callers) refund(item caller) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// The quota is reset at midnight, the requests of the previous day are not refunded.
+	if c.now().UTC().Format("2006-01-02") == c.day && c.used[item.Name] > 0 {
+		c.used[item.Name]--
+	}
+}
@@ -0,0 +1,96 @@
+// Command wcategorization-proxy is the HTTP/HTTPS forward proxy that allows or blocks
+// requests according to the category of the requested website.
+//
+// Usage:
+//
+//	wcategorization-proxy -listen :3128 -deny 10,11 -block-uncategorized
+//
+// Plain HTTP requests of blocked websites receive the block page, category names are translated
+// into the browser language with the catalogs from the -catalogs directory. HTTPS requests are
+// tunneled with the CONNECT method, blocked tunnels are refused with 403 status code.
+package main
+
+import (
+	"context"
+	"flag"
+	"html/template"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	websitecategorization "github.com/whois-api-llc/website-categorization-go"
+	"github.com/whois-api-llc/website-categorization-go/internal/cliutil"
+)
+
+func main() {
+	var (
+		clientFlags  cliutil.ClientFlags
+		webhookFlags cliutil.WebhookFlags
+		policyFlags  cliutil.PolicyFlags
+
+		listen        string
+		blockPagePath string
+		catalogsDir   string
+		failOpen      bool
+	)
+
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	clientFlags.Register(fs)
+	webhookFlags.Register(fs)
+	policyFlags.Register(fs)
+	fs.StringVar(&listen, "listen", ":3128", "address to listen on")
+	fs.StringVar(&blockPagePath, "block-page", "", "html/template file of the block page")
+	fs.StringVar(&catalogsDir, "catalogs", "", "directory with JSON catalogs translating category names on the block page")
+	fs.BoolVar(&failOpen, "fail-open", false, "allow requests when the website cannot be categorized")
+	_ = fs.Parse(os.Args[1:])
+
+	logger := log.New(os.Stderr, "wcategorization-proxy: ", log.LstdFlags)
+
+	ctx := context.Background()
+
+	notifier, err := webhookFlags.NewNotifier(ctx, logger)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	client, err := clientFlags.NewClient(ctx, logger, notifier)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	policy, err := policyFlags.Policy()
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	blockPage := template.Must(template.New("block").Parse(defaultBlockPage))
+	if blockPagePath != "" {
+		blockPage, err = template.ParseFiles(blockPagePath)
+		if err != nil {
+			logger.Fatal(err)
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+
+	handler := newProxy(client, policy, blockPage, failOpen, logger, dialer.DialContext)
+	handler.notifier = notifier
+
+	if catalogsDir != "" {
+		if handler.catalogs, err = websitecategorization.LoadCatalogs(catalogsDir); err != nil {
+			logger.Fatal(err)
+		}
+	}
+
+	server := &http.Server{
+		Addr:              listen,
+		Handler:           handler,
+		ReadHeaderTimeout: 30 * time.Second,
+		ErrorLog:          logger,
+	}
+
+	logger.Printf("listening on %s", listen)
+	logger.Fatal(server.ListenAndServe())
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"html/template"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"time"
+
+	websitecategorization "github.com/whois-api-llc/website-categorization-go"
+	"github.com/whois-api-llc/website-categorization-go/internal/cliutil"
+)
+
+// defaultBlockPage is the block page template used when no custom template is specified.
+const defaultBlockPage = `<!DOCTYPE html>
+<html>
+<head><title>Access denied</title></head>
+<body>
+<h1>Access to {{.Host}} is blocked</h1>
+<p>{{.Reason}}</p>
+{{- if .Categories}}
+<ul>
+{{- range .Categories}}
+<li>{{.Name}} ({{printf "%.2f" .Confidence}})</li>
+{{- end}}
+</ul>
+{{- end}}
+</body>
+</html>
+`
+
+// blockPageData is passed to the block page template.
+type blockPageData struct {
+	Host       string
+	Reason     string
+	Categories []websitecategorization.Category
+}
+
+// proxy is the HTTP forward proxy that filters requests by website category.
+type proxy struct {
+	client    *websitecategorization.Client
+	policy    websitecategorization.Policy
+	blockPage *template.Template
+	failOpen  bool
+	logger    *log.Logger
+
+	// catalogs translate the category names on the block page into the browser language if not nil.
+	catalogs *websitecategorization.Catalogs
+
+	// notifier receives the policy violations if not nil.
+	notifier *cliutil.Notifier
+
+	// dialContext opens connections to the origin servers.
+	dialContext func(ctx context.Context, network, address string) (net.Conn, error)
+
+	forwarder *httputil.ReverseProxy
+}
+
+// newProxy creates proxy. The requests are forwarded with the transport that dials origins with dialContext.
+func newProxy(
+	client *websitecategorization.Client,
+	policy websitecategorization.Policy,
+	blockPage *template.Template,
+	failOpen bool,
+	logger *log.Logger,
+	dialContext func(ctx context.Context, network, address string) (net.Conn, error),
+) *proxy {
+	p := &proxy{
+		client:      client,
+		policy:      policy,
+		blockPage:   blockPage,
+		failOpen:    failOpen,
+		logger:      logger,
+		dialContext: dialContext,
+	}
+
+	p.forwarder = &httputil.ReverseProxy{
+		// The request URL of a proxy request is absolute already.
+		Director: func(*http.Request) {},
+		Transport: &http.Transport{
+			DialContext:           dialContext,
+			MaxIdleConnsPerHost:   10,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: time.Second,
+		},
+		ErrorLog: logger,
+	}
+
+	return p
+}
+
+// ServeHTTP handles both plain HTTP proxy requests and CONNECT tunnels.
+func (p *proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var host string
+
+	if r.Method == http.MethodConnect {
+		host = r.Host
+	} else {
+		if !r.URL.IsAbs() {
+			http.Error(w, "this is a proxy server, requests must use absolute URLs", http.StatusBadRequest)
+
+			return
+		}
+
+		host = r.URL.Host
+	}
+
+	hostname := normalizeHost(host)
+	if hostname == "" {
+		http.Error(w, "missing host", http.StatusBadRequest)
+
+		return
+	}
+
+	if !p.allow(w, r, hostname) {
+		return
+	}
+
+	if r.Method == http.MethodConnect {
+		p.tunnel(w, r)
+
+		return
+	}
+
+	p.forwarder.ServeHTTP(w, r)
+}
+
+// allow categorizes the host and writes the block page if the policy denies it.
+func (p *proxy) allow(w http.ResponseWriter, r *http.Request, hostname string) bool {
+	wCategorizationResp, _, err := p.client.Get(r.Context(), hostname)
+	if err != nil {
+		p.logger.Printf("categorization of %s failed: %v", hostname, err)
+
+		if p.failOpen {
+			return true
+		}
+
+		p.block(w, http.StatusServiceUnavailable, blockPageData{
+			Host:   hostname,
+			Reason: "The website category could not be determined.",
+		})
+
+		return false
+	}
+
+	decision := p.policy.Decide(wCategorizationResp)
+	if !decision.Blocked() {
+		return true
+	}
+
+	p.logger.Printf("blocked %s %s", r.Method, hostname)
+	p.notifier.Notify(websitecategorization.PolicyViolationEvent(wCategorizationResp, decision))
+
+	reason := "The website is not categorized."
+	if len(decision.Matched) > 0 {
+		reason = "The website belongs to a blocked category."
+	}
+
+	p.block(w, http.StatusForbidden, blockPageData{
+		Host:       hostname,
+		Reason:     reason,
+		Categories: p.localize(decision.Matched, r.Header.Get("Accept-Language")),
+	})
+
+	return false
+}
+
+// localize returns the categories with the names translated into the language accepted by the browser.
+func (p *proxy) localize(categories []websitecategorization.Category, acceptLanguage string) []websitecategorization.Category {
+	if p.catalogs == nil {
+		return categories
+	}
+
+	locale := p.catalogs.Match(acceptLanguage)
+	if locale == "" {
+		return categories
+	}
+
+	localized := make([]websitecategorization.Category, len(categories))
+	for i, category := range categories {
+		category.Name = category.LocalizedName(p.catalogs, locale)
+		localized[i] = category
+	}
+
+	return localized
+}
+
+// block writes the block page with the specified status code.
+func (p *proxy) block(w http.ResponseWriter, code int, data blockPageData) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+
+	if err := p.blockPage.Execute(w, data); err != nil {
+		p.logger.Printf("cannot render block page: %v", err)
+	}
+}
+
+// tunnel connects the client to the origin server for CONNECT requests.
+func (p *proxy) tunnel(w http.ResponseWriter, r *http.Request) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "tunneling is not supported", http.StatusInternalServerError)
+
+		return
+	}
+
+	origin, err := p.dialContext(r.Context(), "tcp", r.Host)
+	if err != nil {
+		http.Error(w, "cannot connect to "+r.Host, http.StatusBadGateway)
+
+		return
+	}
+
+	conn, buf, err := hijacker.Hijack()
+	if err != nil {
+		_ = origin.Close()
+		p.logger.Printf("cannot hijack connection: %v", err)
+
+		return
+	}
+
+	if _, err = conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n")); err != nil {
+		_ = conn.Close()
+		_ = origin.Close()
+
+		return
+	}
+
+	done := make(chan struct{}, 2)
+
+	go func() {
+		// Send the data already buffered by the server first.
+		_, _ = io.Copy(origin, buf.Reader)
+		closeWrite(origin)
+		done <- struct{}{}
+	}()
+
+	go func() {
+		_, _ = io.Copy(conn, origin)
+		closeWrite(conn)
+		done <- struct{}{}
+	}()
+
+	<-done
+	<-done
+
+	_ = conn.Close()
+	_ = origin.Close()
+}
+
+// closeWrite shuts down the writing side of the connection if it is supported.
+func closeWrite(conn net.Conn) {
+	if c, ok := conn.(interface{ CloseWrite() error }); ok {
+		_ = c.CloseWrite()
+
+		return
+	}
+
+	_ = conn.Close()
+}
+
+// normalizeHost strips the port and the trailing dot from the host and converts it to lower case.
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"html/template"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	websitecategorization "github.com/whois-api-llc/website-categorization-go"
+	"github.com/whois-api-llc/website-categorization-go/internal/apitest"
+)
+
+// newTestProxy starts the proxy that categorizes hosts with the API server
+// and dials the origin server for every host.
+func newTestProxy(t *testing.T, api *apitest.Server, origin string, failOpen bool) *httptest.Server {
+	t.Helper()
+
+	client := api.NewClient(t, "at_test")
+
+	policy := websitecategorization.Policy{Deny: []int{10}, MinConfidence: 0.5}
+
+	dial := func(ctx context.Context, network, _ string) (net.Conn, error) {
+		var d net.Dialer
+
+		return d.DialContext(ctx, network, origin)
+	}
+
+	p := newProxy(client, policy, template.Must(template.New("block").Parse(defaultBlockPage)), failOpen,
+		log.New(io.Discard, "", 0), dial)
+
+	return httptest.NewServer(p)
+}
+
+// proxyClient returns the HTTP client using the proxy.
+func proxyClient(t *testing.T, proxyServer *httptest.Server) *http.Client {
+	t.Helper()
+
+	proxyURL, err := url.Parse(proxyServer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy: http.ProxyURL(proxyURL),
+			// The origin server certificate is not issued for the test host names.
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, //nolint:gosec
+		},
+	}
+}
+
+var testCategories = map[string][]websitecategorization.Category{
+	"allowed.test": {{ID: 5, Name: "Computer and Internet Info", Confidence: 0.9}},
+	"blocked.test": {{ID: 10, Name: "Gambling", Confidence: 0.8}},
+	"unsure.test":  {{ID: 10, Name: "Gambling", Confidence: 0.3}},
+}
+
+// TestProxyHTTP tests filtering of plain HTTP requests.
+func TestProxyHTTP(t *testing.T) {
+	api := apitest.NewServer(t, testCategories)
+
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = io.WriteString(w, "origin "+req.Host)
+	}))
+	defer origin.Close()
+
+	tests := []struct {
+		name     string
+		url      string
+		failOpen bool
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "allowed",
+			url:      "http://allowed.test/",
+			wantCode: http.StatusOK,
+			wantBody: "origin allowed.test",
+		},
+		{
+			name:     "blocked",
+			url:      "http://blocked.test/page",
+			wantCode: http.StatusForbidden,
+			wantBody: "<li>Gambling (0.80)</li>",
+		},
+		{
+			name:     "low confidence",
+			url:      "http://unsure.test/",
+			wantCode: http.StatusOK,
+			wantBody: "origin unsure.test",
+		},
+		{
+			name:     "API failure",
+			url:      "http://" + apitest.UnreachableDomain + "/",
+			wantCode: http.StatusServiceUnavailable,
+			wantBody: "could not be determined",
+		},
+		{
+			name:     "API failure fail open",
+			url:      "http://" + apitest.UnreachableDomain + "/",
+			failOpen: true,
+			wantCode: http.StatusOK,
+			wantBody: "origin " + apitest.UnreachableDomain,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxyServer := newTestProxy(t, api, origin.Listener.Addr().String(), tt.failOpen)
+			defer proxyServer.Close()
+
+			resp, err := proxyClient(t, proxyServer).Get(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body, _ := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+
+			if resp.StatusCode != tt.wantCode {
+				t.Errorf("status code = %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+
+			if !strings.Contains(string(body), tt.wantBody) {
+				t.Errorf("body = %q, want it to contain %q", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+// TestProxyConnect tests filtering of HTTPS requests tunneled with the CONNECT method.
+func TestProxyConnect(t *testing.T) {
+	api := apitest.NewServer(t, testCategories)
+
+	origin := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = io.WriteString(w, "secure origin")
+	}))
+	defer origin.Close()
+
+	proxyServer := newTestProxy(t, api, origin.Listener.Addr().String(), false)
+	defer proxyServer.Close()
+
+	client := proxyClient(t, proxyServer)
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get("https://allowed.test/")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+
+		if string(body) != "secure origin" {
+			t.Errorf("body = %q, want %q", body, "secure origin")
+		}
+
+		client.CloseIdleConnections()
+	}
+
+	if _, err := client.Get("https://blocked.test/"); err == nil || !strings.Contains(err.Error(), "Forbidden") {
+		t.Errorf("blocked tunnel error = %v, want Forbidden", err)
+	}
+
+	if got := api.Requests(); got != 2 {
+		t.Errorf("API requests = %d, want 2 as repeated hosts must be served from the cache", got)
+	}
+}
+
+// TestProxyLocalize tests the translation of category names on the block page.
+func TestProxyLocalize(t *testing.T) {
+	catalog, err := websitecategorization.NewCatalog("de", map[int]string{10: "Glücksspiel"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &proxy{catalogs: websitecategorization.NewCatalogs(catalog)}
+
+	categories := testCategories["blocked.test"]
+
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{acceptLanguage: "de-DE,de;q=0.9,en;q=0.8", want: "Glücksspiel"},
+		{acceptLanguage: "fr", want: "Gambling"},
+		{acceptLanguage: "", want: "Gambling"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.acceptLanguage, func(t *testing.T) {
+			if got := p.localize(categories, tt.acceptLanguage); got[0].Name != tt.want {
+				t.Errorf("localize() = %v, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if categories[0].Name != "Gambling" {
+		t.Errorf("localize() modified the categories: %v", categories)
+	}
+}
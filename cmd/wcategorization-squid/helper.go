@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	websitecategorization "github.com/whois-api-llc/website-categorization-go"
+	"github.com/whois-api-llc/website-categorization-go/internal/cliutil"
+)
+
+// helper answers the requests of the Squid external ACL helper protocol. Every request line contains
+// the channel ID when Squid runs the helper with concurrency, followed by the URL-encoded host or URL.
+// The answer is OK when the policy blocks the host and ERR when it allows the host.
+type helper struct {
+	client   *websitecategorization.Client
+	policy   websitecategorization.Policy
+	failOpen bool
+	logger   *log.Logger
+
+	// notifier receives the policy violations if not nil.
+	notifier *cliutil.Notifier
+
+	// channelIDs means that request lines start with the channel ID.
+	channelIDs bool
+
+	// concurrency is the maximum number of simultaneously answered requests with channel IDs.
+	concurrency int
+}
+
+// serve answers the requests read from r until it ends. Requests with channel IDs are answered concurrently,
+// so the answers can be written in a different order.
+func (h *helper) serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+
+	bw := bufio.NewWriter(w)
+
+	write := func(line string) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		_, _ = bw.WriteString(line + "\n")
+
+		// Squid waits for the answer, so it is not kept in the buffer.
+		if err := bw.Flush(); err != nil {
+			h.logger.Printf("cannot write answer: %v", err)
+		}
+	}
+
+	concurrency := h.concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	slots := make(chan struct{}, concurrency)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		if !h.channelIDs {
+			write(h.answer(ctx, fields))
+
+			continue
+		}
+
+		channel, args := fields[0], fields[1:]
+
+		slots <- struct{}{}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			write(channel + " " + h.answer(ctx, args))
+		}()
+	}
+
+	wg.Wait()
+
+	return scanner.Err()
+}
+
+// answer returns the answer to the request arguments without the channel ID.
+func (h *helper) answer(ctx context.Context, args []string) string {
+	if len(args) == 0 {
+		return "BH message=" + escape("missing host")
+	}
+
+	host := requestHost(args[0])
+	if host == "" {
+		return "BH message=" + escape("invalid host "+args[0])
+	}
+
+	wCategorizationResp, _, err := h.client.Get(ctx, host)
+	if err != nil {
+		h.logger.Printf("categorization of %s failed: %v", host, err)
+
+		if h.failOpen {
+			return "ERR message=" + escape("website category could not be determined")
+		}
+
+		return "OK message=" + escape("website category could not be determined")
+	}
+
+	decision := h.policy.Decide(wCategorizationResp)
+
+	result := "ERR"
+	if decision.Blocked() {
+		result = "OK"
+
+		h.notifier.Notify(websitecategorization.PolicyViolationEvent(wCategorizationResp, decision))
+	}
+
+	return result + categoryTags(wCategorizationResp, decision)
+}
+
+// categoryTags returns the key-value pairs describing the categories: tag with the IDs of the matched categories
+// or of all categories if the verdict is not based on categories, and categories with their names.
+func categoryTags(resp *websitecategorization.WCategorizationResponse, decision websitecategorization.Decision) string {
+	categories := decision.Matched
+	if len(categories) == 0 {
+		categories = resp.CategoriesAbove(0)
+	}
+
+	if len(categories) == 0 {
+		return " tag=uncategorized"
+	}
+
+	ids := make([]string, len(categories))
+	names := make([]string, len(categories))
+
+	for i, category := range categories {
+		ids[i] = strconv.Itoa(category.ID)
+		names[i] = category.Name
+	}
+
+	return " tag=" + escape(strings.Join(ids, ",")) + " categories=" + escape(strings.Join(names, ","))
+}
+
+// requestHost returns the host name of the URL-encoded host, host:port or URL argument.
+func requestHost(arg string) string {
+	value, err := url.PathUnescape(arg)
+	if err != nil {
+		return ""
+	}
+
+	if strings.Contains(value, "://") {
+		u, err := url.Parse(value)
+		if err != nil {
+			return ""
+		}
+
+		value = u.Host
+	}
+
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		value = host
+	}
+
+	return strings.TrimSuffix(strings.Trim(strings.ToLower(value), "[]"), ".")
+}
+
+// escape encodes the value of the answer key-value pair.
+func escape(value string) string {
+	return url.PathEscape(value)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	websitecategorization "github.com/whois-api-llc/website-categorization-go"
+	"github.com/whois-api-llc/website-categorization-go/internal/apitest"
+	"github.com/whois-api-llc/website-categorization-go/internal/cliutil"
+)
+
+var testCategories = map[string][]websitecategorization.Category{
+	"allowed.test": {{ID: 5, Name: "Computer and Internet Info", Confidence: 0.9}},
+	"blocked.test": {{ID: 10, Name: "Gambling", Confidence: 0.8}, {ID: 3, Name: "Shopping", Confidence: 0.6}},
+}
+
+// newTestHelper returns the helper that categorizes hosts with the fake API server.
+func newTestHelper(t *testing.T) (*helper, *apitest.Server) {
+	t.Helper()
+
+	api := apitest.NewServer(t, testCategories)
+
+	return &helper{
+		client:      api.NewClient(t, "at_test"),
+		policy:      websitecategorization.Policy{Deny: []int{10}, MinConfidence: 0.5},
+		logger:      log.New(io.Discard, "", 0),
+		channelIDs:  true,
+		concurrency: 4,
+	}, api
+}
+
+// TestHelperConcurrent tests the answers to the requests with channel IDs.
+func TestHelperConcurrent(t *testing.T) {
+	h, api := newTestHelper(t)
+
+	input := strings.Join([]string{
+		"0 blocked.test",
+		"1 allowed.test",
+		"2 Blocked.Test:443",
+		"3 http%3A%2F%2Fallowed.test%2Fpath",
+		"4 unknown.test",
+		"5 " + apitest.UnreachableDomain,
+		"",
+		"6",
+		"7 blocked.test",
+	}, "\n") + "\n"
+
+	var out bytes.Buffer
+	if err := h.serve(context.Background(), strings.NewReader(input), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	sort.Strings(lines)
+
+	want := []string{
+		"0 OK tag=10 categories=Gambling",
+		"1 ERR tag=5 categories=Computer%20and%20Internet%20Info",
+		"2 OK tag=10 categories=Gambling",
+		"3 ERR tag=5 categories=Computer%20and%20Internet%20Info",
+		"4 ERR tag=uncategorized",
+		"5 OK message=website%20category%20could%20not%20be%20determined",
+		"6 BH message=missing%20host",
+		"7 OK tag=10 categories=Gambling",
+	}
+
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("answers =\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Categorized hosts are answered from the cache.
+	requested := api.Requests()
+
+	out.Reset()
+
+	if err := h.serve(context.Background(), strings.NewReader("8 blocked.test\n9 allowed.test\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := api.Requests(); n != requested {
+		t.Errorf("API requests = %d, want %d", n, requested)
+	}
+}
+
+// TestHelperSequential tests the answers to the requests without channel IDs.
+func TestHelperSequential(t *testing.T) {
+	h, _ := newTestHelper(t)
+	h.channelIDs = false
+	h.failOpen = true
+
+	var out bytes.Buffer
+	if err := h.serve(context.Background(), strings.NewReader("blocked.test\nunreachable.test\nallowed.test\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "OK tag=10 categories=Gambling\n" +
+		"ERR message=website%20category%20could%20not%20be%20determined\n" +
+		"ERR tag=5 categories=Computer%20and%20Internet%20Info\n"
+
+	if out.String() != want {
+		t.Errorf("answers =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+// TestHelperNotify tests that the blocked hosts are posted to the webhooks.
+func TestHelperNotify(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []websitecategorization.WebhookEvent
+	)
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var event websitecategorization.WebhookEvent
+		_ = json.NewDecoder(req.Body).Decode(&event)
+
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	}))
+	defer receiver.Close()
+
+	h, _ := newTestHelper(t)
+	h.channelIDs = false
+
+	flags := cliutil.WebhookFlags{URLs: receiver.URL, Events: string(websitecategorization.EventPolicyViolation)}
+
+	var err error
+	if h.notifier, err = flags.NewNotifier(context.Background(), h.logger); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = h.serve(context.Background(), strings.NewReader("blocked.test\nallowed.test\n"), io.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	h.notifier.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(events) != 1 || events[0].Type != websitecategorization.EventPolicyViolation {
+		t.Fatalf("events = %+v, want one policy violation", events)
+	}
+
+	if data, _ := events[0].Data.(map[string]interface{}); data["domainName"] != "blocked.test" {
+		t.Errorf("event data = %v, want blocked.test", events[0].Data)
+	}
+}
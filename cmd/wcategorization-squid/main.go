@@ -0,0 +1,67 @@
+// Command wcategorization-squid is the Squid external ACL helper that matches requests by website category.
+//
+// Usage in squid.conf:
+//
+//	external_acl_type wcategorization concurrency=50 ttl=3600 negative_ttl=3600 %DST /usr/local/bin/wcategorization-squid -deny 10,11
+//	acl blocked_category external wcategorization
+//	http_access deny blocked_category
+//
+// The helper answers OK for hosts blocked by the policy and ERR for allowed hosts. The answers carry
+// the tag with the category IDs and the categories with their names. Run the helper with -channel-ids=false
+// when Squid is configured with concurrency=0. Categorization results are cached, so repeated requests
+// do not cause API requests.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/whois-api-llc/website-categorization-go/internal/cliutil"
+)
+
+func main() {
+	var (
+		clientFlags  cliutil.ClientFlags
+		webhookFlags cliutil.WebhookFlags
+		policyFlags  cliutil.PolicyFlags
+
+		h helper
+	)
+
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	clientFlags.Register(fs)
+	webhookFlags.Register(fs)
+	policyFlags.Register(fs)
+	fs.BoolVar(&h.channelIDs, "channel-ids", true, "request lines start with channel IDs, set it to false for concurrency=0")
+	fs.IntVar(&h.concurrency, "concurrency", 50, "maximum number of simultaneously answered requests")
+	fs.BoolVar(&h.failOpen, "fail-open", false, "allow hosts when they cannot be categorized")
+	_ = fs.Parse(os.Args[1:])
+
+	// The standard output is reserved for the answers.
+	h.logger = log.New(os.Stderr, "wcategorization-squid: ", log.LstdFlags)
+
+	ctx := context.Background()
+
+	var err error
+
+	if h.notifier, err = webhookFlags.NewNotifier(ctx, h.logger); err != nil {
+		h.logger.Fatal(err)
+	}
+
+	if h.client, err = clientFlags.NewClient(ctx, h.logger, h.notifier); err != nil {
+		h.logger.Fatal(err)
+	}
+
+	if h.policy, err = policyFlags.Policy(); err != nil {
+		h.logger.Fatal(err)
+	}
+
+	if err = h.serve(ctx, os.Stdin, os.Stdout); err != nil {
+		h.logger.Fatal(err)
+	}
+
+	// The queued events are delivered before the helper exits.
+	h.notifier.Close()
+}
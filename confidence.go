@@ -0,0 +1,92 @@
+package websitecategorization
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+// defaultMinConfidence is the minimum confidence used by the API when OptionMinConfidence is not specified.
+const defaultMinConfidence = 0.55
+
+// TopCategory returns the category with the highest confidence.
+// The second value is false if the response has no categories.
+func (r *WCategorizationResponse) TopCategory() (Category, bool) {
+	var top Category
+
+	if len(r.Categories) == 0 {
+		return top, false
+	}
+
+	top = r.Categories[0]
+	for _, category := range r.Categories[1:] {
+		if category.Confidence > top.Confidence {
+			top = category
+		}
+	}
+
+	return top, true
+}
+
+// CategoriesAbove returns the categories with the confidence greater than or equal to the threshold
+// in the original order.
+func (r *WCategorizationResponse) CategoriesAbove(threshold float64) []Category {
+	var categories []Category
+
+	for _, category := range r.Categories {
+		if category.Confidence >= threshold {
+			categories = append(categories, category)
+		}
+	}
+
+	return categories
+}
+
+// HasCategory reports whether the response contains the category with the specified ID.
+func (r *WCategorizationResponse) HasCategory(id int) bool {
+	for _, category := range r.Categories {
+		if category.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SortByConfidence sorts the categories by confidence in descending order.
+// Categories with equal confidence are ordered by ID.
+func (r *WCategorizationResponse) SortByConfidence() {
+	sort.SliceStable(r.Categories, func(i, j int) bool {
+		if r.Categories[i].Confidence != r.Categories[j].Confidence {
+			return r.Categories[i].Confidence > r.Categories[j].Confidence
+		}
+
+		return r.Categories[i].ID < r.Categories[j].ID
+	})
+}
+
+// WithMinConfidence returns a copy of the response with the categories below the threshold removed.
+// It gives the same result as a request made with OptionMinConfidence(threshold) if the response was
+// requested with a lower threshold, so a single request serves several thresholds.
+func (r *WCategorizationResponse) WithMinConfidence(threshold float64) *WCategorizationResponse {
+	c := r.clone()
+	c.Categories = r.CategoriesAbove(threshold)
+
+	if c.Categories == nil && r.Categories != nil {
+		c.Categories = []Category{}
+	}
+
+	return c
+}
+
+// requestedMinConfidence returns the minimum confidence the options request.
+func requestedMinConfidence(opts []Option) float64 {
+	query := url.Values{}
+	applyOptions(query, opts)
+
+	if value, err := strconv.ParseFloat(query.Get("minConfidence"), 64); err == nil {
+		return value
+	}
+
+	return defaultMinConfidence
+}
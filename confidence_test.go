@@ -0,0 +1,117 @@
+package websitecategorization
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+// TestConfidenceHelpers tests the WCategorizationResponse confidence helpers.
+func TestConfidenceHelpers(t *testing.T) {
+	resp := &WCategorizationResponse{
+		DomainName: "whoisxmlapi.com",
+		Categories: []Category{
+			{ID: 4, Confidence: 0.6},
+			{ID: 5, Confidence: 0.85},
+			{ID: 2, Confidence: 0.6},
+		},
+	}
+
+	if top, ok := resp.TopCategory(); !ok || top.ID != 5 {
+		t.Errorf("TopCategory() = %v, %v", top, ok)
+	}
+
+	if _, ok := (&WCategorizationResponse{}).TopCategory(); ok {
+		t.Error("TopCategory() of empty response must return false")
+	}
+
+	if got := resp.CategoriesAbove(0.6); len(got) != 3 {
+		t.Errorf("CategoriesAbove(0.6) = %v", got)
+	}
+
+	if got := resp.CategoriesAbove(0.7); len(got) != 1 || got[0].ID != 5 {
+		t.Errorf("CategoriesAbove(0.7) = %v", got)
+	}
+
+	if !resp.HasCategory(2) || resp.HasCategory(3) {
+		t.Error("HasCategory() returned unexpected result")
+	}
+
+	filtered := resp.WithMinConfidence(0.9)
+	if filtered.Categories == nil || len(filtered.Categories) != 0 || len(resp.Categories) != 3 {
+		t.Errorf("WithMinConfidence(0.9) = %v, original %v", filtered.Categories, resp.Categories)
+	}
+
+	resp.SortByConfidence()
+
+	want := []Category{{ID: 5, Confidence: 0.85}, {ID: 2, Confidence: 0.6}, {ID: 4, Confidence: 0.6}}
+	if !reflect.DeepEqual(resp.Categories, want) {
+		t.Errorf("SortByConfidence() = %v, want %v", resp.Categories, want)
+	}
+}
+
+// TestCacheMinConfidence tests that requests with different thresholds are served from a single cache entry.
+func TestCacheMinConfidence(t *testing.T) {
+	var requests int32
+
+	categories := []Category{{ID: 5, Confidence: 0.85}, {ID: 4, Confidence: 0.6}, {ID: 2, Confidence: 0.2}}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+
+		threshold, err := strconv.ParseFloat(req.URL.Query().Get("minConfidence"), 64)
+		if err != nil {
+			threshold = defaultMinConfidence
+		}
+
+		resp := WCategorizationResponse{DomainName: req.URL.Query().Get("domainName")}
+		resp.Categories = (&WCategorizationResponse{Categories: categories}).CategoriesAbove(threshold)
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	apiURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewClient(apiKey, ClientParams{
+		HTTPClient:             server.Client(),
+		WCategorizationBaseURL: apiURL,
+		Cache:                  NewMemoryCache(0, 0),
+		CacheMinConfidence:     0.3,
+	})
+
+	tests := []struct {
+		opts []Option
+		want int
+	}{
+		{nil, 2},
+		{[]Option{OptionMinConfidence(0.8)}, 1},
+		{[]Option{OptionMinConfidence(0.3)}, 2},
+		{[]Option{OptionMinConfidence(0.1)}, 3},
+	}
+
+	for _, tt := range tests {
+		resp, _, err := client.Get(context.Background(), "whoisxmlapi.com", tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(resp.Categories) != tt.want {
+			t.Errorf("Client.Get() threshold %v categories = %v, want %d", requestedMinConfidence(tt.opts),
+				resp.Categories, tt.want)
+		}
+	}
+
+	// The threshold below CacheMinConfidence is requested separately.
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("API requests = %d, want 2", got)
+	}
+}
@@ -0,0 +1,470 @@
+package websitecategorization
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CSVColumn is the column of the CSV representation of WCategorizationResponse.
+// Column names are JSON paths of the corresponding response fields.
+type CSVColumn string
+
+// CSV columns.
+const (
+	CSVColumnDomainName         CSVColumn = "domainName"
+	CSVColumnWebsiteResponded   CSVColumn = "websiteResponded"
+	CSVColumnCreatedDate        CSVColumn = "createdDate"
+	CSVColumnCategoryID         CSVColumn = "categories.id"
+	CSVColumnCategoryName       CSVColumn = "categories.name"
+	CSVColumnCategoryConfidence CSVColumn = "categories.confidence"
+	CSVColumnASN                CSVColumn = "as.asn"
+	CSVColumnASDomain           CSVColumn = "as.domain"
+	CSVColumnASName             CSVColumn = "as.name"
+	CSVColumnASRoute            CSVColumn = "as.route"
+	CSVColumnASType             CSVColumn = "as.type"
+	CSVColumnMatchedDomain      CSVColumn = "matchedDomain"
+	CSVColumnSource             CSVColumn = "source"
+)
+
+// DefaultCSVColumns is the list of columns used when no columns are specified.
+var DefaultCSVColumns = []CSVColumn{
+	CSVColumnDomainName,
+	CSVColumnWebsiteResponded,
+	CSVColumnCreatedDate,
+	CSVColumnCategoryID,
+	CSVColumnCategoryName,
+	CSVColumnCategoryConfidence,
+	CSVColumnASN,
+	CSVColumnASDomain,
+	CSVColumnASName,
+	CSVColumnASRoute,
+	CSVColumnASType,
+	CSVColumnMatchedDomain,
+	CSVColumnSource,
+}
+
+// CSVLayout defines how categories are flattened into CSV rows.
+type CSVLayout int
+
+const (
+	// CSVRowPerDomain writes one row per domain. Category columns contain the values of all
+	// categories in the response order joined with CSVCategorySeparator.
+	CSVRowPerDomain CSVLayout = iota
+
+	// CSVRowPerCategory writes one row per domain-category pair. Domain and AS columns are
+	// repeated in every row. A domain without categories is written as a single row with
+	// empty category columns.
+	CSVRowPerCategory
+)
+
+// CSVCategorySeparator separates category values in the CSVRowPerDomain layout.
+const CSVCategorySeparator = "|"
+
+// ErrUnknownCSVColumn is returned when the CSV header contains an unsupported column.
+var ErrUnknownCSVColumn = errors.New("unknown CSV column")
+
+// isCategoryColumn reports whether the column holds category values.
+func isCategoryColumn(column CSVColumn) bool {
+	return column == CSVColumnCategoryID || column == CSVColumnCategoryName || column == CSVColumnCategoryConfidence
+}
+
+// isKnownColumn reports whether the column is supported.
+func isKnownColumn(column CSVColumn) bool {
+	for _, c := range DefaultCSVColumns {
+		if c == column {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CSVWriter writes WCategorizationResponse values as CSV records. The header is written before the first record.
+type CSVWriter struct {
+	w       *csv.Writer
+	layout  CSVLayout
+	columns []CSVColumn
+
+	headerWritten bool
+}
+
+// NewCSVWriter creates CSVWriter with the specified layout and columns. DefaultCSVColumns are used if
+// no columns are specified.
+func NewCSVWriter(w io.Writer, layout CSVLayout, columns ...CSVColumn) *CSVWriter {
+	if len(columns) == 0 {
+		columns = DefaultCSVColumns
+	}
+
+	return &CSVWriter{
+		w:       csv.NewWriter(w),
+		layout:  layout,
+		columns: columns,
+	}
+}
+
+// Write writes the response as one or more CSV records.
+func (w *CSVWriter) Write(resp *WCategorizationResponse) error {
+	if !w.headerWritten {
+		header := make([]string, len(w.columns))
+
+		for i, column := range w.columns {
+			if !isKnownColumn(column) {
+				return fmt.Errorf("%w: %s", ErrUnknownCSVColumn, column)
+			}
+
+			header[i] = string(column)
+		}
+
+		if err := w.w.Write(header); err != nil {
+			return err
+		}
+
+		w.headerWritten = true
+	}
+
+	for _, category := range resp.Categories {
+		if strings.Contains(category.Name, CSVCategorySeparator) {
+			return &ArgError{"Categories", "name " + strconv.Quote(category.Name) +
+				" contains the separator " + strconv.Quote(CSVCategorySeparator)}
+		}
+	}
+
+	if w.layout == CSVRowPerCategory && len(resp.Categories) > 0 {
+		for i := range resp.Categories {
+			if err := w.w.Write(w.record(resp, resp.Categories[i:i+1])); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return w.w.Write(w.record(resp, resp.Categories))
+}
+
+// Flush writes buffered records to the underlying writer.
+func (w *CSVWriter) Flush() error {
+	w.w.Flush()
+
+	return w.w.Error()
+}
+
+// record returns the CSV record of the response with the specified categories.
+func (w *CSVWriter) record(resp *WCategorizationResponse, categories []Category) []string {
+	record := make([]string, len(w.columns))
+
+	for i, column := range w.columns {
+		if isCategoryColumn(column) {
+			values := make([]string, len(categories))
+			for j, category := range categories {
+				values[j] = categoryValue(column, category)
+			}
+
+			record[i] = strings.Join(values, CSVCategorySeparator)
+
+			continue
+		}
+
+		record[i] = responseValue(column, resp)
+	}
+
+	return record
+}
+
+// categoryValue returns the value of the category column.
+func categoryValue(column CSVColumn, category Category) string {
+	switch column {
+	case CSVColumnCategoryID:
+		return strconv.Itoa(category.ID)
+	case CSVColumnCategoryName:
+		return category.Name
+	case CSVColumnCategoryConfidence:
+		return strconv.FormatFloat(category.Confidence, 'f', -1, 64)
+	}
+
+	return ""
+}
+
+// responseValue returns the value of the domain or AS column.
+func responseValue(column CSVColumn, resp *WCategorizationResponse) string {
+	switch column {
+	case CSVColumnDomainName:
+		return resp.DomainName
+	case CSVColumnWebsiteResponded:
+		return strconv.FormatBool(resp.WebsiteResponded)
+	case CSVColumnCreatedDate:
+		if resp.CreatedDate != nil {
+			return *resp.CreatedDate
+		}
+
+		return ""
+	case CSVColumnMatchedDomain:
+		return resp.MatchedDomain
+	case CSVColumnSource:
+		return string(resp.Source)
+	}
+
+	if resp.AS == nil {
+		return ""
+	}
+
+	switch column {
+	case CSVColumnASN:
+		return strconv.Itoa(resp.AS.ASN)
+	case CSVColumnASDomain:
+		return resp.AS.Domain
+	case CSVColumnASName:
+		return resp.AS.Name
+	case CSVColumnASRoute:
+		return resp.AS.Route
+	case CSVColumnASType:
+		return resp.AS.Type
+	}
+
+	return ""
+}
+
+// CSVReader reads WCategorizationResponse values written by CSVWriter. Columns are determined by the header.
+// Missing columns leave the corresponding fields empty.
+type CSVReader struct {
+	r       *csv.Reader
+	layout  CSVLayout
+	columns []CSVColumn
+
+	// pending is the first record of the next response in the CSVRowPerCategory layout.
+	pending []string
+}
+
+// NewCSVReader creates CSVReader for the specified layout.
+func NewCSVReader(r io.Reader, layout CSVLayout) *CSVReader {
+	return &CSVReader{
+		r:      csv.NewReader(r),
+		layout: layout,
+	}
+}
+
+// Read returns the next response. It returns io.EOF when there are no more responses.
+// In the CSVRowPerCategory layout consecutive rows with the same domain name are merged into one response.
+func (r *CSVReader) Read() (*WCategorizationResponse, error) {
+	if r.columns == nil {
+		if err := r.readHeader(); err != nil {
+			return nil, err
+		}
+	}
+
+	record := r.pending
+	r.pending = nil
+
+	if record == nil {
+		var err error
+
+		if record, err = r.r.Read(); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := r.parse(record)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.layout != CSVRowPerCategory {
+		return resp, nil
+	}
+
+	for {
+		next, err := r.r.Read()
+		if err == io.EOF {
+			return resp, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		nextResp, err := r.parse(next)
+		if err != nil {
+			return nil, err
+		}
+
+		if nextResp.DomainName != resp.DomainName {
+			r.pending = next
+
+			return resp, nil
+		}
+
+		resp.Categories = append(resp.Categories, nextResp.Categories...)
+	}
+}
+
+// readHeader reads the column names.
+func (r *CSVReader) readHeader() error {
+	header, err := r.r.Read()
+	if err != nil {
+		return err
+	}
+
+	columns := make([]CSVColumn, len(header))
+
+	for i, name := range header {
+		columns[i] = CSVColumn(name)
+		if !isKnownColumn(columns[i]) {
+			return fmt.Errorf("%w: %s", ErrUnknownCSVColumn, name)
+		}
+	}
+
+	r.columns = columns
+
+	return nil
+}
+
+// categoryCount returns the number of categories in the record. An empty value of a category column
+// is a single empty value if other category columns have one value, e.g. the name of an unnamed category.
+func (r *CSVReader) categoryCount(record []string) (int, error) {
+	count := -1
+
+	for i, column := range r.columns {
+		if !isCategoryColumn(column) || record[i] == "" {
+			continue
+		}
+
+		n := strings.Count(record[i], CSVCategorySeparator) + 1
+		if count >= 0 && n != count {
+			line, _ := r.r.FieldPos(i)
+
+			return 0, fmt.Errorf("line %d: number of categories in %s column does not match", line, column)
+		}
+
+		count = n
+	}
+
+	if count < 0 {
+		return 0, nil
+	}
+
+	for i, column := range r.columns {
+		if isCategoryColumn(column) && record[i] == "" && count > 1 {
+			line, _ := r.r.FieldPos(i)
+
+			return 0, fmt.Errorf("line %d: number of categories in %s column does not match", line, column)
+		}
+	}
+
+	return count, nil
+}
+
+// parse converts the CSV record to the response.
+func (r *CSVReader) parse(record []string) (*WCategorizationResponse, error) {
+	var (
+		resp  WCategorizationResponse
+		as    AS
+		hasAS bool
+	)
+
+	count, err := r.categoryCount(record)
+	if err != nil {
+		return nil, err
+	}
+
+	categories := make([][]string, count)
+
+	for i, column := range r.columns {
+		value := record[i]
+
+		if isCategoryColumn(column) {
+			if count == 0 {
+				continue
+			}
+
+			for j, v := range strings.Split(value, CSVCategorySeparator) {
+				categories[j] = append(categories[j], string(column), v)
+			}
+
+			continue
+		}
+
+		if value == "" {
+			continue
+		}
+
+		switch column {
+		case CSVColumnDomainName:
+			resp.DomainName = value
+		case CSVColumnWebsiteResponded:
+			resp.WebsiteResponded, err = strconv.ParseBool(value)
+		case CSVColumnCreatedDate:
+			createdDate := value
+			resp.CreatedDate = &createdDate
+		case CSVColumnASN:
+			as.ASN, err = strconv.Atoi(value)
+		case CSVColumnASDomain:
+			as.Domain = value
+		case CSVColumnASName:
+			as.Name = value
+		case CSVColumnASRoute:
+			as.Route = value
+		case CSVColumnASType:
+			as.Type = value
+		case CSVColumnMatchedDomain:
+			resp.MatchedDomain = value
+		case CSVColumnSource:
+			resp.Source = ResultSource(value)
+		}
+
+		if err != nil {
+			line, _ := r.r.FieldPos(i)
+
+			return nil, fmt.Errorf("line %d: invalid %s value: %w", line, column, err)
+		}
+
+		hasAS = hasAS || strings.HasPrefix(string(column), "as.")
+	}
+
+	if hasAS {
+		resp.AS = &as
+	}
+
+	for _, fields := range categories {
+		category, err := parseCategoryFields(fields)
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Categories = append(resp.Categories, category)
+	}
+
+	return &resp, nil
+}
+
+// parseCategoryFields converts column-value pairs to the category.
+func parseCategoryFields(fields []string) (Category, error) {
+	var (
+		category Category
+		err      error
+	)
+
+	for i := 0; i+1 < len(fields); i += 2 {
+		if fields[i+1] == "" {
+			continue
+		}
+
+		switch CSVColumn(fields[i]) {
+		case CSVColumnCategoryID:
+			category.ID, err = strconv.Atoi(fields[i+1])
+		case CSVColumnCategoryName:
+			category.Name = fields[i+1]
+		case CSVColumnCategoryConfidence:
+			category.Confidence, err = strconv.ParseFloat(fields[i+1], 64)
+		}
+
+		if err != nil {
+			return category, fmt.Errorf("invalid %s value: %w", fields[i], err)
+		}
+	}
+
+	return category, nil
+}
@@ -0,0 +1,209 @@
+package websitecategorization
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testResponses returns the sample responses for encoding tests.
+func testResponses() []*WCategorizationResponse {
+	createdDate := "2009-03-19T21:47:17+00:00"
+
+	return []*WCategorizationResponse{
+		{
+			AS: &AS{
+				ASN:    13335,
+				Domain: "https://www.cloudflare.com",
+				Name:   "CLOUDFLARENET",
+				Route:  "104.26.0.0/20",
+				Type:   "Content",
+			},
+			DomainName: "whoisxmlapi.com",
+			Categories: []Category{
+				{Confidence: 0.85, ID: 5, Name: "Computer and Internet Info"},
+				{Confidence: 0.6, ID: 4, Name: "Business and Economy"},
+			},
+			CreatedDate:      &createdDate,
+			WebsiteResponded: true,
+		},
+		{
+			DomainName: "unknown.example",
+		},
+	}
+}
+
+// TestCSVWriter tests the flattening rules of the CSV layouts.
+func TestCSVWriter(t *testing.T) {
+	tests := []struct {
+		name    string
+		layout  CSVLayout
+		columns []CSVColumn
+		want    string
+	}{
+		{
+			name:   "row per domain",
+			layout: CSVRowPerDomain,
+			want: `domainName,websiteResponded,createdDate,categories.id,categories.name,categories.confidence,` +
+				`as.asn,as.domain,as.name,as.route,as.type,matchedDomain,source
+whoisxmlapi.com,true,2009-03-19T21:47:17+00:00,5|4,Computer and Internet Info|Business and Economy,0.85|0.6,` +
+				`13335,https://www.cloudflare.com,CLOUDFLARENET,104.26.0.0/20,Content,,
+unknown.example,false,,,,,,,,,,,
+`,
+		},
+		{
+			name:   "row per category",
+			layout: CSVRowPerCategory,
+			columns: []CSVColumn{
+				CSVColumnDomainName,
+				CSVColumnCategoryID,
+				CSVColumnCategoryConfidence,
+				CSVColumnASN,
+			},
+			want: `domainName,categories.id,categories.confidence,as.asn
+whoisxmlapi.com,5,0.85,13335
+whoisxmlapi.com,4,0.6,13335
+unknown.example,,,
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			w := NewCSVWriter(&buf, tt.layout, tt.columns...)
+			for _, resp := range testResponses() {
+				if err := w.Write(resp); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := buf.String(); got != tt.want {
+				t.Errorf("CSVWriter output = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCSVRoundTrip tests that CSVReader restores the responses written by CSVWriter.
+func TestCSVRoundTrip(t *testing.T) {
+	for _, layout := range []CSVLayout{CSVRowPerDomain, CSVRowPerCategory} {
+		var buf bytes.Buffer
+
+		w := NewCSVWriter(&buf, layout)
+		for _, resp := range testResponses() {
+			if err := w.Write(resp); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+
+		r := NewCSVReader(&buf, layout)
+
+		var got []*WCategorizationResponse
+
+		for {
+			resp, err := r.Read()
+			if err == io.EOF {
+				break
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got = append(got, resp)
+		}
+
+		if want := testResponses(); !reflect.DeepEqual(got, want) {
+			t.Errorf("layout %d: CSVReader.Read() = %+v, want %+v", layout, got, want)
+		}
+	}
+}
+
+// TestCSVRoundTripUnnamed tests the responses with unnamed categories, e.g. the overridden ones.
+func TestCSVRoundTripUnnamed(t *testing.T) {
+	tests := []struct {
+		name string
+		resp *WCategorizationResponse
+	}{
+		{
+			name: "single unnamed category",
+			resp: &WCategorizationResponse{
+				DomainName:    "blog.example.com",
+				Categories:    []Category{{ID: 7, Confidence: 1}},
+				MatchedDomain: "example.com",
+				Source:        SourceOverride,
+			},
+		},
+		{
+			name: "unnamed and named categories",
+			resp: &WCategorizationResponse{
+				DomainName: "example.org",
+				Categories: []Category{{ID: 7, Confidence: 1}, {ID: 3, Name: "Shopping", Confidence: 0.5}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		for _, layout := range []CSVLayout{CSVRowPerDomain, CSVRowPerCategory} {
+			var buf bytes.Buffer
+
+			w := NewCSVWriter(&buf, layout)
+			if err := w.Write(tt.resp); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := NewCSVReader(&buf, layout).Read()
+			if err != nil {
+				t.Fatalf("%s, layout %d: CSVReader.Read() error = %v", tt.name, layout, err)
+			}
+
+			if !reflect.DeepEqual(got, tt.resp) {
+				t.Errorf("%s, layout %d: CSVReader.Read() = %+v, want %+v", tt.name, layout, got, tt.resp)
+			}
+		}
+	}
+}
+
+// TestCSVErrors tests the CSV encoding errors.
+func TestCSVErrors(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := NewCSVWriter(&buf, CSVRowPerDomain, "unknown").Write(testResponses()[0])
+	if !errors.Is(err, ErrUnknownCSVColumn) {
+		t.Errorf("CSVWriter.Write() error = %v, want %v", err, ErrUnknownCSVColumn)
+	}
+
+	err = NewCSVWriter(&buf, CSVRowPerDomain).Write(&WCategorizationResponse{
+		Categories: []Category{{Name: "Arts|Entertainment"}},
+	})
+	if err == nil {
+		t.Error("CSVWriter.Write() must fail on names containing the separator")
+	}
+
+	_, err = NewCSVReader(strings.NewReader("domainName,categories.id,categories.name\na.com,1|2,One\n"),
+		CSVRowPerDomain).Read()
+	if err == nil || !strings.Contains(err.Error(), "number of categories") {
+		t.Errorf("CSVReader.Read() error = %v, want mismatch error", err)
+	}
+
+	_, err = NewCSVReader(strings.NewReader("domainName,categories.id,categories.name\na.com,1|2,\n"),
+		CSVRowPerDomain).Read()
+	if err == nil || !strings.Contains(err.Error(), "number of categories") {
+		t.Errorf("CSVReader.Read() error = %v, want mismatch error for the empty column", err)
+	}
+}
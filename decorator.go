@@ -0,0 +1,392 @@
+package websitecategorization
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Decorator wraps WCategorizationService with additional behavior and returns another WCategorizationService.
+// Decorators work at the level of domain lookups, so they see the domain names, options and parsed results
+// rather than HTTP requests. Use ClientParams.Decorators to create Client with the decorated service.
+type Decorator func(WCategorizationService) WCategorizationService
+
+// Decorate wraps the service with the decorators. The first decorator is the outermost one,
+// i.e. it is called first and receives the result of the rest of the stack.
+func Decorate(service WCategorizationService, decorators ...Decorator) WCategorizationService {
+	for i := len(decorators) - 1; i >= 0; i-- {
+		service = decorators[i](service)
+	}
+
+	return service
+}
+
+// WithCache returns the Decorator that serves Get requests from the cache, see ClientParams.Cache
+// and ClientParams.CacheMinConfidence. Raw requests and categories requests are passed through as is.
+func WithCache(cache Cache, minConfidence float64) Decorator {
+	return func(service WCategorizationService) WCategorizationService {
+		return &cachingService{
+			WCategorizationService: service,
+			cache:                  cache,
+			minConfidence:          minConfidence,
+		}
+	}
+}
+
+// WithDefaultOptions returns the Decorator that adds the options to every request, see ClientParams.DefaultOptions.
+func WithDefaultOptions(opts ...Option) Decorator {
+	return func(service WCategorizationService) WCategorizationService {
+		return &defaultOptionsService{
+			WCategorizationService: service,
+			defaults:               opts,
+		}
+	}
+}
+
+// WithValidation returns the Decorator that fails requests with an empty domain name or invalid options
+// with ArgError without calling the wrapped service.
+func WithValidation() Decorator {
+	return func(service WCategorizationService) WCategorizationService {
+		return &validatingService{WCategorizationService: service}
+	}
+}
+
+// validatingService is the WCategorizationService that checks the request arguments.
+type validatingService struct {
+	WCategorizationService
+}
+
+// validate checks the domain name and the options.
+func (s *validatingService) validate(domainName string, opts []Option) error {
+	if domainName == "" {
+		return &ArgError{"domainName", "can not be empty"}
+	}
+
+	return ValidateOptions(opts...)
+}
+
+// Get returns parsed Website Categorization API response.
+func (s *validatingService) Get(ctx context.Context, domainName string, opts ...Option) (
+	*WCategorizationResponse, *Response, error) {
+	if err := s.validate(domainName, opts); err != nil {
+		return nil, nil, err
+	}
+
+	return s.WCategorizationService.Get(ctx, domainName, opts...)
+}
+
+// GetRaw returns raw Website Categorization API response.
+func (s *validatingService) GetRaw(ctx context.Context, domainName string, opts ...Option) (*Response, error) {
+	if err := s.validate(domainName, opts); err != nil {
+		return nil, err
+	}
+
+	return s.WCategorizationService.GetRaw(ctx, domainName, opts...)
+}
+
+// GetAllCategories returns all possible categories.
+func (s *validatingService) GetAllCategories(ctx context.Context, opts ...Option) (
+	[]CategoryItem, *Response, error) {
+	if err := ValidateOptions(opts...); err != nil {
+		return nil, nil, err
+	}
+
+	return s.WCategorizationService.GetAllCategories(ctx, opts...)
+}
+
+// GetAllCategoriesRaw returns all possible categories as a raw API response.
+func (s *validatingService) GetAllCategoriesRaw(ctx context.Context, opts ...Option) (*Response, error) {
+	if err := ValidateOptions(opts...); err != nil {
+		return nil, err
+	}
+
+	return s.WCategorizationService.GetAllCategoriesRaw(ctx, opts...)
+}
+
+// ServiceCall describes the completed call of a WCategorizationService method.
+type ServiceCall struct {
+	// Method is the name of the called method, e.g. "Get".
+	Method string
+
+	// DomainName is the requested domain name, empty for categories requests.
+	DomainName string
+
+	// StatusCode is the HTTP status code of the API response, zero if there is no response.
+	StatusCode int
+
+	// Cached is true if the Get result is served from the cache.
+	Cached bool
+
+	// Duration is the duration of the call.
+	Duration time.Duration
+
+	// Err is the error returned by the call.
+	Err error
+}
+
+// Metrics receives the completed calls of the decorated service. Implementations must be safe for concurrent use.
+type Metrics interface {
+	// ObserveCall records the call.
+	ObserveCall(call ServiceCall)
+}
+
+// MetricsFunc is the function used as Metrics.
+type MetricsFunc func(call ServiceCall)
+
+// ObserveCall calls f(call).
+func (f MetricsFunc) ObserveCall(call ServiceCall) {
+	f(call)
+}
+
+// WithMetrics returns the Decorator that reports every call to the metrics. Place it outside WithCache
+// to observe cache hits.
+func WithMetrics(metrics Metrics) Decorator {
+	return func(service WCategorizationService) WCategorizationService {
+		return &observingService{WCategorizationService: service, observe: metrics.ObserveCall}
+	}
+}
+
+// Logger is the logger used by WithLogging, *log.Logger satisfies it.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// WithLogging returns the Decorator that logs every call with its outcome and duration.
+// API keys are never logged.
+func WithLogging(logger Logger) Decorator {
+	return WithMetrics(MetricsFunc(func(call ServiceCall) {
+		target := call.Method
+		if call.DomainName != "" {
+			target += " " + call.DomainName
+		}
+
+		switch {
+		case call.Err != nil:
+			logger.Printf("%s failed in %s: %v", target, call.Duration, call.Err)
+		case call.Cached:
+			logger.Printf("%s served from cache in %s", target, call.Duration)
+		default:
+			logger.Printf("%s returned %d in %s", target, call.StatusCode, call.Duration)
+		}
+	}))
+}
+
+// observingService is the WCategorizationService that reports every call.
+type observingService struct {
+	WCategorizationService
+
+	observe func(call ServiceCall)
+}
+
+// done reports the call started at the specified time.
+func (s *observingService) done(call ServiceCall, started time.Time, resp *Response) {
+	call.Duration = time.Since(started)
+
+	if resp != nil && resp.Response != nil {
+		call.StatusCode = resp.StatusCode
+	}
+
+	s.observe(call)
+}
+
+// Get returns parsed Website Categorization API response.
+func (s *observingService) Get(ctx context.Context, domainName string, opts ...Option) (
+	*WCategorizationResponse, *Response, error) {
+	started := time.Now()
+
+	wCategorizationResp, resp, err := s.WCategorizationService.Get(ctx, domainName, opts...)
+	s.done(ServiceCall{Method: "Get", DomainName: domainName, Cached: err == nil && resp == nil, Err: err}, started, resp)
+
+	return wCategorizationResp, resp, err
+}
+
+// GetRaw returns raw Website Categorization API response.
+func (s *observingService) GetRaw(ctx context.Context, domainName string, opts ...Option) (*Response, error) {
+	started := time.Now()
+
+	resp, err := s.WCategorizationService.GetRaw(ctx, domainName, opts...)
+	s.done(ServiceCall{Method: "GetRaw", DomainName: domainName, Err: err}, started, resp)
+
+	return resp, err
+}
+
+// GetAllCategories returns all possible categories.
+func (s *observingService) GetAllCategories(ctx context.Context, opts ...Option) (
+	[]CategoryItem, *Response, error) {
+	started := time.Now()
+
+	categories, resp, err := s.WCategorizationService.GetAllCategories(ctx, opts...)
+	s.done(ServiceCall{Method: "GetAllCategories", Err: err}, started, resp)
+
+	return categories, resp, err
+}
+
+// GetAllCategoriesRaw returns all possible categories as a raw API response.
+func (s *observingService) GetAllCategoriesRaw(ctx context.Context, opts ...Option) (*Response, error) {
+	started := time.Now()
+
+	resp, err := s.WCategorizationService.GetAllCategoriesRaw(ctx, opts...)
+	s.done(ServiceCall{Method: "GetAllCategoriesRaw", Err: err}, started, resp)
+
+	return resp, err
+}
+
+// RetryPolicy describes how WithRetries repeats failed requests.
+type RetryPolicy struct {
+	// Attempts is the maximum number of attempts including the first one. Default: 3.
+	Attempts int
+
+	// Backoff is the delay before the first retry, it doubles with every next retry. Default: 500 milliseconds.
+	Backoff time.Duration
+
+	// MaxBackoff is the maximum delay between attempts. Default: 10 seconds.
+	MaxBackoff time.Duration
+}
+
+// delay returns the delay before the specified retry starting from 1.
+func (p RetryPolicy) delay(retry int) time.Duration {
+	backoff := p.Backoff
+	if backoff <= 0 {
+		backoff = 500 * time.Millisecond
+	}
+
+	maxBackoff := p.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = 10 * time.Second
+	}
+
+	for i := 1; i < retry && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > maxBackoff {
+		return maxBackoff
+	}
+
+	return backoff
+}
+
+// WithRetries returns the Decorator that repeats requests failed with transient errors: network errors,
+// rate limiting and server errors. Invalid arguments, exceeded budgets and other API errors are returned at once.
+func WithRetries(policy RetryPolicy) Decorator {
+	if policy.Attempts <= 0 {
+		policy.Attempts = 3
+	}
+
+	return func(service WCategorizationService) WCategorizationService {
+		return &retryingService{WCategorizationService: service, policy: policy}
+	}
+}
+
+// retryingService is the WCategorizationService that repeats failed requests.
+type retryingService struct {
+	WCategorizationService
+
+	policy RetryPolicy
+}
+
+// retry calls the function until it succeeds, fails with a permanent error or the attempts are over.
+// The function returns the API response if there is one, so the requests answered with the error pages
+// of gateways, which cannot be parsed, are repeated according to their status codes.
+func (s *retryingService) retry(ctx context.Context, call func() (*Response, error)) error {
+	for attempt := 1; ; attempt++ {
+		resp, err := call()
+		if err == nil || attempt >= s.policy.Attempts || !retryable(ctx, err) && !retryableResponse(ctx, resp) {
+			return err
+		}
+
+		timer := time.NewTimer(s.policy.delay(attempt))
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+
+			return err
+		}
+	}
+}
+
+// Get returns parsed Website Categorization API response.
+func (s *retryingService) Get(ctx context.Context, domainName string, opts ...Option) (
+	wCategorizationResp *WCategorizationResponse, resp *Response, err error) {
+	err = s.retry(ctx, func() (*Response, error) {
+		wCategorizationResp, resp, err = s.WCategorizationService.Get(ctx, domainName, opts...)
+
+		return resp, err
+	})
+
+	return wCategorizationResp, resp, err
+}
+
+// GetRaw returns raw Website Categorization API response.
+func (s *retryingService) GetRaw(ctx context.Context, domainName string, opts ...Option) (resp *Response, err error) {
+	err = s.retry(ctx, func() (*Response, error) {
+		resp, err = s.WCategorizationService.GetRaw(ctx, domainName, opts...)
+
+		return resp, err
+	})
+
+	return resp, err
+}
+
+// GetAllCategories returns all possible categories.
+func (s *retryingService) GetAllCategories(ctx context.Context, opts ...Option) (
+	categories []CategoryItem, resp *Response, err error) {
+	err = s.retry(ctx, func() (*Response, error) {
+		categories, resp, err = s.WCategorizationService.GetAllCategories(ctx, opts...)
+
+		return resp, err
+	})
+
+	return categories, resp, err
+}
+
+// GetAllCategoriesRaw returns all possible categories as a raw API response.
+func (s *retryingService) GetAllCategoriesRaw(ctx context.Context, opts ...Option) (resp *Response, err error) {
+	err = s.retry(ctx, func() (*Response, error) {
+		resp, err = s.WCategorizationService.GetAllCategoriesRaw(ctx, opts...)
+
+		return resp, err
+	})
+
+	return resp, err
+}
+
+// retryable reports whether the request failed with a transient error.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var (
+		errResp *ErrorResponse
+		errMsg  *ErrorMessage
+		urlErr  *url.Error
+		netErr  net.Error
+	)
+
+	switch {
+	case errors.As(err, &errResp):
+		return retryableStatus(errResp.Response.StatusCode)
+	case errors.As(err, &errMsg):
+		return retryableStatus(errMsg.Code)
+	case errors.As(err, &urlErr), errors.As(err, &netErr):
+		return true
+	}
+
+	return false
+}
+
+// retryableResponse reports whether the API answered with the status code of a transient error.
+func retryableResponse(ctx context.Context, resp *Response) bool {
+	return ctx.Err() == nil && resp != nil && resp.Response != nil && retryableStatus(resp.StatusCode)
+}
+
+// retryableStatus reports whether the request failed with the status code may succeed when repeated.
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
@@ -0,0 +1,437 @@
+package websitecategorization
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeService is WCategorizationService returning the queued errors before succeeding.
+type fakeService struct {
+	mu    sync.Mutex
+	calls []string
+	errs  []error
+}
+
+// call records the call and returns the next queued error.
+func (s *fakeService) call(method string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls = append(s.calls, method)
+
+	if len(s.errs) == 0 {
+		return nil
+	}
+
+	err := s.errs[0]
+	s.errs = s.errs[1:]
+
+	return err
+}
+
+// response returns the successful API response.
+func (s *fakeService) response() *Response {
+	return &Response{Response: &http.Response{StatusCode: http.StatusOK}, Body: []byte(`{}`)}
+}
+
+// Get returns the response with the requested domain name.
+func (s *fakeService) Get(_ context.Context, domainName string, _ ...Option) (
+	*WCategorizationResponse, *Response, error) {
+	if err := s.call("Get"); err != nil {
+		return nil, nil, err
+	}
+
+	return &WCategorizationResponse{DomainName: domainName}, s.response(), nil
+}
+
+// GetRaw returns the empty response.
+func (s *fakeService) GetRaw(context.Context, string, ...Option) (*Response, error) {
+	if err := s.call("GetRaw"); err != nil {
+		return nil, err
+	}
+
+	return s.response(), nil
+}
+
+// GetAllCategories returns the single category.
+func (s *fakeService) GetAllCategories(context.Context, ...Option) ([]CategoryItem, *Response, error) {
+	if err := s.call("GetAllCategories"); err != nil {
+		return nil, nil, err
+	}
+
+	return []CategoryItem{{ID: 1, Name: "Arts"}}, s.response(), nil
+}
+
+// GetAllCategoriesRaw returns the empty response.
+func (s *fakeService) GetAllCategoriesRaw(context.Context, ...Option) (*Response, error) {
+	if err := s.call("GetAllCategoriesRaw"); err != nil {
+		return nil, err
+	}
+
+	return s.response(), nil
+}
+
+// TestDecorate tests the order of decorators.
+func TestDecorate(t *testing.T) {
+	var order []string
+
+	tracing := func(name string) Decorator {
+		return WithMetrics(MetricsFunc(func(ServiceCall) {
+			order = append(order, name)
+		}))
+	}
+
+	service := Decorate(&fakeService{}, tracing("outer"), tracing("inner"))
+
+	if _, _, err := service.Get(context.Background(), "example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The inner decorator completes first.
+	if got := strings.Join(order, ","); got != "inner,outer" {
+		t.Errorf("completion order = %s, want inner,outer", got)
+	}
+}
+
+// TestWithValidation tests that invalid requests do not reach the wrapped service.
+func TestWithValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		domainName string
+		opts       []Option
+		wantErr    string
+	}{
+		{
+			name:       "valid",
+			domainName: "example.com",
+			opts:       []Option{OptionMinConfidence(0.7)},
+		},
+		{
+			name:       "empty domain name",
+			domainName: "",
+			wantErr:    `invalid argument: "domainName" can not be empty`,
+		},
+		{
+			name:       "invalid option",
+			domainName: "example.com",
+			opts:       []Option{OptionMinConfidence(1.5)},
+			wantErr:    `invalid argument: "minConfidence" must be between 0.00 and 1.00`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeService{}
+			service := Decorate(fake, WithValidation())
+
+			_, err := service.GetRaw(context.Background(), tt.domainName, tt.opts...)
+
+			if tt.wantErr == "" {
+				if err != nil || len(fake.calls) != 1 {
+					t.Errorf("GetRaw() error = %v, calls = %v", err, fake.calls)
+				}
+
+				return
+			}
+
+			var argErr *ArgError
+			if !errors.As(err, &argErr) || err.Error() != tt.wantErr {
+				t.Errorf("GetRaw() error = %v, want %s", err, tt.wantErr)
+			}
+
+			if len(fake.calls) != 0 {
+				t.Errorf("wrapped service is called: %v", fake.calls)
+			}
+		})
+	}
+}
+
+// TestWithRetries tests that only transient errors are retried.
+func TestWithRetries(t *testing.T) {
+	serverErr := &ErrorResponse{Response: &http.Response{StatusCode: http.StatusBadGateway}}
+	rateLimited := &ErrorMessage{Code: http.StatusTooManyRequests, Message: "Too many requests"}
+	networkErr := fmt.Errorf("cannot execute request: %w", &url.Error{Op: "Get", URL: "https://api.test", Err: errors.New("reset")})
+
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantErr   bool
+	}{
+		{
+			name:      "success",
+			wantCalls: 1,
+		},
+		{
+			name:      "server error",
+			errs:      []error{serverErr},
+			wantCalls: 2,
+		},
+		{
+			name:      "rate limited and network error",
+			errs:      []error{rateLimited, networkErr},
+			wantCalls: 3,
+		},
+		{
+			name:      "attempts are over",
+			errs:      []error{serverErr, serverErr, serverErr, serverErr},
+			wantCalls: 3,
+			wantErr:   true,
+		},
+		{
+			name:      "client error",
+			errs:      []error{&ErrorResponse{Response: &http.Response{StatusCode: http.StatusUnprocessableEntity}}},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "budget exceeded",
+			errs:      []error{&BudgetExceededError{Scope: BudgetScopeDaily, Limit: 1}},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "invalid argument",
+			errs:      []error{&ArgError{"domainName", "can not be empty"}},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeService{errs: tt.errs}
+			service := Decorate(fake, WithRetries(RetryPolicy{Backoff: time.Millisecond}))
+
+			categories, _, err := service.GetAllCategories(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetAllCategories() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err == nil && len(categories) != 1 {
+				t.Errorf("GetAllCategories() = %v", categories)
+			}
+
+			if len(fake.calls) != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", len(fake.calls), tt.wantCalls)
+			}
+		})
+	}
+}
+
+// TestWithRetriesCanceled tests that retries stop when the context is canceled.
+func TestWithRetriesCanceled(t *testing.T) {
+	fake := &fakeService{errs: []error{&ErrorMessage{Code: http.StatusServiceUnavailable}}}
+	service := Decorate(fake, WithRetries(RetryPolicy{Backoff: time.Hour}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, _, err := service.Get(ctx, "example.com"); err == nil {
+		t.Error("Get() must fail")
+	}
+
+	if len(fake.calls) != 1 {
+		t.Errorf("calls = %d, want 1", len(fake.calls))
+	}
+}
+
+// TestRetryPolicyDelay tests the exponential backoff.
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+
+	for retry, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if got := policy.delay(retry + 1); got != want {
+			t.Errorf("delay(%d) = %s, want %s", retry+1, got, want)
+		}
+	}
+}
+
+// TestWithMetrics tests the reported calls including cache hits.
+func TestWithMetrics(t *testing.T) {
+	var calls []ServiceCall
+
+	fake := &fakeService{errs: []error{nil, errors.New("failed")}}
+	service := Decorate(fake,
+		WithMetrics(MetricsFunc(func(call ServiceCall) { calls = append(calls, call) })),
+		WithCache(NewMemoryCache(0, 0), 0),
+	)
+
+	ctx := context.Background()
+
+	_, _, _ = service.Get(ctx, "example.com")
+	_, _, _ = service.Get(ctx, "example.com")
+	_, _ = service.GetRaw(ctx, "example.com")
+	_, _ = service.GetAllCategoriesRaw(ctx)
+
+	want := []ServiceCall{
+		{Method: "Get", DomainName: "example.com", StatusCode: http.StatusOK},
+		{Method: "Get", DomainName: "example.com", Cached: true},
+		{Method: "GetRaw", DomainName: "example.com"},
+		{Method: "GetAllCategoriesRaw", StatusCode: http.StatusOK},
+	}
+
+	if len(calls) != len(want) {
+		t.Fatalf("calls = %+v, want %+v", calls, want)
+	}
+
+	for i := range want {
+		got := calls[i]
+		if got.Method != want[i].Method || got.DomainName != want[i].DomainName ||
+			got.StatusCode != want[i].StatusCode || got.Cached != want[i].Cached {
+			t.Errorf("call %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+
+	if calls[2].Err == nil {
+		t.Error("GetRaw error is not reported")
+	}
+}
+
+// logRecorder is Logger that keeps the formatted lines.
+type logRecorder struct {
+	lines []string
+}
+
+// Printf records the line.
+func (l *logRecorder) Printf(format string, v ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+// TestWithLogging tests the logged outcomes.
+func TestWithLogging(t *testing.T) {
+	logger := &logRecorder{}
+
+	fake := &fakeService{errs: []error{errors.New("connection refused")}}
+	service := Decorate(fake, WithLogging(logger))
+
+	ctx := context.Background()
+
+	_, _, _ = service.Get(ctx, "example.com")
+	_, _, _ = service.GetAllCategories(ctx)
+
+	want := []string{"Get example.com failed in ", "connection refused", "GetAllCategories returned 200 in "}
+
+	log := strings.Join(logger.lines, "\n")
+	for _, s := range want {
+		if !strings.Contains(log, s) {
+			t.Errorf("log = %q, want it to contain %q", log, s)
+		}
+	}
+}
+
+// TestClientDecorators tests that the client service is wrapped with the decorators.
+func TestClientDecorators(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte(`{"domainName":"` + req.URL.Query().Get("domainName") + `","websiteResponded":true}`))
+	}))
+	defer server.Close()
+
+	apiURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var calls []ServiceCall
+
+	client := NewClient(apiKey, ClientParams{
+		HTTPClient:             server.Client(),
+		WCategorizationBaseURL: apiURL,
+		Cache:                  NewMemoryCache(0, 0),
+		Decorators: []Decorator{
+			WithValidation(),
+			WithMetrics(MetricsFunc(func(call ServiceCall) { calls = append(calls, call) })),
+		},
+	})
+
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, _, err = client.Get(ctx, "example.com"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, _, err = client.Get(ctx, ""); err == nil {
+		t.Error("Get() with empty domain name must fail")
+	}
+
+	if len(calls) != 2 || calls[0].Cached || !calls[1].Cached {
+		t.Errorf("calls = %+v, want a request and a cache hit", calls)
+	}
+}
+
+// TestWithRetriesErrorPage tests that the requests answered with the error pages of gateways are repeated.
+func TestWithRetriesErrorPage(t *testing.T) {
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("<html><body>503 Service Unavailable</body></html>"))
+	}))
+	defer server.Close()
+
+	apiURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewClient(apiKey, ClientParams{
+		HTTPClient:             server.Client(),
+		WCategorizationBaseURL: apiURL,
+		Decorators:             []Decorator{WithRetries(RetryPolicy{Attempts: 3, Backoff: time.Millisecond})},
+	})
+
+	tests := []struct {
+		name string
+		call func(ctx context.Context) error
+	}{
+		{
+			name: "Get",
+			call: func(ctx context.Context) error {
+				_, _, err := client.Get(ctx, "example.com")
+
+				return err
+			},
+		},
+		{
+			name: "GetRaw",
+			call: func(ctx context.Context) error {
+				_, err := client.GetRaw(ctx, "example.com")
+
+				return err
+			},
+		},
+		{
+			name: "GetAllCategories",
+			call: func(ctx context.Context) error {
+				_, _, err := client.GetAllCategories(ctx)
+
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			atomic.StoreInt32(&requests, 0)
+
+			if err := tt.call(context.Background()); err == nil {
+				t.Errorf("%s() error = nil, want the error of the last attempt", tt.name)
+			}
+
+			if n := atomic.LoadInt32(&requests); n != 3 {
+				t.Errorf("requests = %d, want 3", n)
+			}
+		})
+	}
+}
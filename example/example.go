@@ -14,7 +14,7 @@ func GetData(apikey string) {
 	wCategorizationResp, resp, err := client.Get(context.Background(),
 		"whoisxmlapi.com",
 		// this option is ignored, as the inner parser works with JSON only.
-		websitecategorization.OptionOutputFormat("XML"))
+		websitecategorization.OptionOutputFormat(websitecategorization.OutputFormatXML))
 
 	if err != nil {
 		// Handle error message returned by server.
@@ -59,7 +59,7 @@ func GetCategories(apikey string) {
 	// Get all possible categories as an array.
 	wCategorizationResp, _, err := client.WCategorizationService.GetAllCategories(context.Background(),
 		// this option causes the categories to be ordered alphabetically by the name field.
-		websitecategorization.OptionOrder("ABC"))
+		websitecategorization.OptionOrder(websitecategorization.OrderABC))
 
 	if err != nil {
 		// Handle error message returned by server.
@@ -0,0 +1,114 @@
+package websitecategorization
+
+import (
+	"context"
+	"strings"
+
+	"github.com/whois-api-llc/website-categorization-go/publicsuffix"
+)
+
+// defaultFallbackLookups is the default number of extra lookups made by the subdomain fallback.
+const defaultFallbackLookups = 2
+
+// SubdomainFallback is the policy of looking up parent domains of host names without an informative result,
+// e.g. blog.example.com and example.com for shop.blog.example.com.
+type SubdomainFallback struct {
+	// MaxLookups is the maximum number of extra lookups per Get. Default: 2.
+	MaxLookups int
+
+	// List is the Public Suffix List, parent domains are looked up down to the registrable domain.
+	// Default: the list embedded in the publicsuffix package.
+	List *publicsuffix.List
+
+	// Informative reports whether the response describes the website well enough to be returned.
+	// Default: the response has categories and the website responded.
+	Informative func(resp *WCategorizationResponse) bool
+}
+
+// parents returns the parent domains of the host name to be looked up, the nearest first.
+func (f *SubdomainFallback) parents(host string) []string {
+	list := f.List
+	if list == nil {
+		list = publicsuffix.Default()
+	}
+
+	name := strings.TrimSuffix(strings.ToLower(host), ".")
+
+	registrable, err := list.RegistrableDomain(name)
+	if err != nil {
+		return nil
+	}
+
+	maxLookups := f.MaxLookups
+	if maxLookups <= 0 {
+		maxLookups = defaultFallbackLookups
+	}
+
+	// The labels are counted rather than compared, as the list returns the ASCII form of internationalized names.
+	labels := strings.Split(name, ".")
+	registrableLabels := strings.Count(registrable, ".") + 1
+
+	var parents []string
+
+	for i := 1; len(labels)-i >= registrableLabels && len(parents) < maxLookups; i++ {
+		parents = append(parents, strings.Join(labels[i:], "."))
+	}
+
+	return parents
+}
+
+// informative reports whether the response is informative.
+func (f *SubdomainFallback) informative(resp *WCategorizationResponse) bool {
+	if f.Informative != nil {
+		return f.Informative(resp)
+	}
+
+	return len(resp.Categories) > 0 && resp.WebsiteResponded
+}
+
+// WithSubdomainFallback returns the Decorator that looks up parent domains when Get returns
+// an uninformative result for the host name. The first informative result of a parent is returned
+// with DomainName set to the requested name and MatchedDomain set to the parent. If no parent has
+// an informative result or a parent lookup fails, the result for the host name itself is returned.
+// Raw requests are passed through as is.
+func WithSubdomainFallback(fallback SubdomainFallback) Decorator {
+	return func(service WCategorizationService) WCategorizationService {
+		return &fallbackService{WCategorizationService: service, fallback: fallback}
+	}
+}
+
+// fallbackService is the WCategorizationService that falls back to parent domains.
+type fallbackService struct {
+	WCategorizationService
+
+	fallback SubdomainFallback
+}
+
+// Get returns parsed Website Categorization API response for the host name or its nearest parent domain.
+func (s *fallbackService) Get(ctx context.Context, domainName string, opts ...Option) (
+	*WCategorizationResponse, *Response, error) {
+	wCategorizationResp, resp, err := s.WCategorizationService.Get(ctx, domainName, opts...)
+	if err != nil || s.fallback.informative(wCategorizationResp) {
+		return wCategorizationResp, resp, err
+	}
+
+	for _, parent := range s.fallback.parents(domainName) {
+		parentResp, parentRawResp, parentErr := s.WCategorizationService.Get(ctx, parent, opts...)
+		if parentErr != nil {
+			break
+		}
+
+		if s.fallback.informative(parentResp) {
+			parentResp.MatchedDomain = parentResp.DomainName
+			if parentResp.MatchedDomain == "" {
+				parentResp.MatchedDomain = parent
+			}
+
+			parentResp.DomainName = domainName
+
+			return parentResp, parentRawResp, nil
+		}
+	}
+
+	return wCategorizationResp, resp, nil
+}
@@ -0,0 +1,115 @@
+package websitecategorization
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestSubdomainFallback tests the lookups of parent domains.
+func TestSubdomainFallback(t *testing.T) {
+	categorized := map[string]*WCategorizationResponse{
+		"example.com":      {DomainName: "example.com", WebsiteResponded: true, Categories: []Category{{ID: 5}}},
+		"blog.example.com": {DomainName: "blog.example.com", WebsiteResponded: false, Categories: []Category{{ID: 7}}},
+		"example.co.uk":    {DomainName: "example.co.uk", WebsiteResponded: true, Categories: []Category{{ID: 9}}},
+		"failing.com":      {DomainName: "failing.com", WebsiteResponded: true, Categories: []Category{{ID: 9}}},
+	}
+
+	tests := []struct {
+		name         string
+		fallback     SubdomainFallback
+		host         string
+		wantMatched  string
+		wantCategory int
+		wantLookups  []string
+	}{
+		{
+			name:         "informative host",
+			host:         "example.com",
+			wantMatched:  "",
+			wantLookups:  []string{"example.com"},
+			wantCategory: 5,
+		},
+		{
+			name:         "parent",
+			host:         "shop.blog.example.com",
+			wantMatched:  "example.com",
+			wantCategory: 5,
+			wantLookups:  []string{"shop.blog.example.com", "blog.example.com", "example.com"},
+		},
+		{
+			name:        "lookups are capped",
+			fallback:    SubdomainFallback{MaxLookups: 1},
+			host:        "shop.blog.example.com",
+			wantLookups: []string{"shop.blog.example.com", "blog.example.com"},
+		},
+		{
+			name:        "public suffix is not looked up",
+			host:        "www.nothing.co.uk",
+			wantLookups: []string{"www.nothing.co.uk", "nothing.co.uk"},
+		},
+		{
+			name:         "multi-label public suffix",
+			host:         "a.example.co.uk",
+			wantMatched:  "example.co.uk",
+			wantCategory: 9,
+			wantLookups:  []string{"a.example.co.uk", "example.co.uk"},
+		},
+		{
+			name:        "failed parent lookup",
+			host:        "www.failing.com",
+			wantLookups: []string{"www.failing.com", "failing.com"},
+		},
+		{
+			name: "custom informative",
+			fallback: SubdomainFallback{Informative: func(resp *WCategorizationResponse) bool {
+				return len(resp.Categories) > 0
+			}},
+			host:         "shop.blog.example.com",
+			wantMatched:  "blog.example.com",
+			wantCategory: 7,
+			wantLookups:  []string{"shop.blog.example.com", "blog.example.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var lookups []string
+
+			stub := &stubService{get: func(_ context.Context, domainName string) (*WCategorizationResponse, error) {
+				lookups = append(lookups, domainName)
+
+				if domainName == "failing.com" {
+					return nil, errors.New("failed")
+				}
+
+				if resp, ok := categorized[domainName]; ok {
+					return resp.clone(), nil
+				}
+
+				return &WCategorizationResponse{DomainName: domainName, WebsiteResponded: true}, nil
+			}}
+
+			resp, _, err := Decorate(stub, WithSubdomainFallback(tt.fallback)).Get(context.Background(), tt.host)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.DomainName != tt.host {
+				t.Errorf("DomainName = %q, want %q", resp.DomainName, tt.host)
+			}
+
+			if resp.MatchedDomain != tt.wantMatched {
+				t.Errorf("MatchedDomain = %q, want %q", resp.MatchedDomain, tt.wantMatched)
+			}
+
+			if tt.wantCategory != 0 && !resp.HasCategory(tt.wantCategory) {
+				t.Errorf("Categories = %v, want %d", resp.Categories, tt.wantCategory)
+			}
+
+			if !reflect.DeepEqual(lookups, tt.wantLookups) {
+				t.Errorf("lookups = %v, want %v", lookups, tt.wantLookups)
+			}
+		})
+	}
+}
@@ -0,0 +1,370 @@
+package websitecategorization
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/whois-api-llc/website-categorization-go/publicsuffix"
+)
+
+// SourceHeuristic marks responses guessed by HeuristicClassifier from the domain name.
+const SourceHeuristic ResultSource = "heuristic"
+
+const (
+	// HeuristicMaxConfidence is the maximum confidence of categories guessed by HeuristicClassifier.
+	HeuristicMaxConfidence = 0.5
+
+	// heuristicTLDConfidence is the confidence of a category hinted by the public suffix.
+	heuristicTLDConfidence = 0.4
+
+	// heuristicKeywordConfidence is the confidence of a category hinted by a single keyword.
+	heuristicKeywordConfidence = 0.3
+
+	// heuristicKeywordBonus is added for every additional keyword of the same category.
+	heuristicKeywordBonus = 0.05
+
+	// minSubstringKeyword is the minimum length of keywords matched inside tokens,
+	// shorter keywords match whole tokens only.
+	minSubstringKeyword = 4
+)
+
+// HeuristicRule lists the hints of a category.
+type HeuristicRule struct {
+	// Category is the category name as returned by GetAllCategories, compared case-insensitively.
+	Category string `json:"category"`
+
+	// Keywords are the lowercase words found in domain names of the category.
+	Keywords []string `json:"keywords,omitempty"`
+
+	// TLDs are the public suffixes of the category. A TLD also matches the public suffixes
+	// having it as a label, e.g. "edu" matches "edu.au".
+	TLDs []string `json:"tlds,omitempty"`
+}
+
+// DefaultHeuristicRules are the built-in hints. Rules of the categories missing from the directory are ignored.
+var DefaultHeuristicRules = []HeuristicRule{
+	{Category: "Gambling", Keywords: []string{"casino", "poker", "bet", "betting", "slots", "lottery", "bingo", "roulette",
+		"blackjack", "sportsbook", "wager"}, TLDs: []string{"casino", "bet", "poker"}},
+	{Category: "Adult", Keywords: []string{"porn", "xxx", "sex", "nude", "erotic", "escort", "camgirl"},
+		TLDs: []string{"xxx", "porn", "adult", "sex"}},
+	{Category: "Shopping", Keywords: []string{"shop", "store", "buy", "deals", "outlet", "mall", "cart", "boutique"},
+		TLDs: []string{"shop", "store", "shopping"}},
+	{Category: "News", Keywords: []string{"news", "times", "herald", "gazette", "tribune", "daily", "journal", "headlines"},
+		TLDs: []string{"news"}},
+	{Category: "Games", Keywords: []string{"game", "games", "gaming", "arcade", "minecraft", "esports"},
+		TLDs: []string{"game", "games"}},
+	{Category: "Financial Services", Keywords: []string{"bank", "banking", "credit", "loan", "loans", "finance", "insurance",
+		"mortgage", "invest", "payment"}, TLDs: []string{"bank", "insurance", "finance"}},
+	{Category: "Real Estate", Keywords: []string{"realty", "realestate", "homes", "property", "apartments", "rent"},
+		TLDs: []string{"realty", "properties"}},
+	{Category: "Educational Institutions", Keywords: []string{"university", "college", "school", "academy", "campus"},
+		TLDs: []string{"edu", "ac", "school", "university"}},
+	{Category: "Government", Keywords: []string{"gov", "government", "ministry", "council", "municipal"},
+		TLDs: []string{"gov", "gouv", "gob"}},
+	{Category: "Military", Keywords: []string{"army", "navy", "military", "airforce"}, TLDs: []string{"mil"}},
+	{Category: "Health and Medicine", Keywords: []string{"health", "clinic", "hospital", "pharmacy", "medical", "doctor",
+		"dental"}, TLDs: []string{"health", "clinic", "hospital"}},
+	{Category: "Travel", Keywords: []string{"travel", "hotel", "hotels", "flights", "tours", "booking", "vacation"},
+		TLDs: []string{"travel", "hotel", "tours"}},
+	{Category: "Sports", Keywords: []string{"sport", "sports", "football", "soccer", "basketball", "tennis", "golf"},
+		TLDs: []string{"sport", "football", "golf"}},
+	{Category: "Job Search", Keywords: []string{"jobs", "careers", "hiring", "recruit", "resume"},
+		TLDs: []string{"jobs", "careers"}},
+	{Category: "Streaming Media", Keywords: []string{"stream", "streaming", "video", "movies", "tube", "watch"},
+		TLDs: []string{"tv", "movie", "video"}},
+	{Category: "Music", Keywords: []string{"music", "radio", "songs", "lyrics", "mp3"}, TLDs: []string{"music", "radio"}},
+	{Category: "Weapons", Keywords: []string{"guns", "firearms", "ammo", "weapons", "rifle"}},
+	{Category: "Alcohol and Tobacco", Keywords: []string{"beer", "wine", "vodka", "whisky", "vape", "tobacco", "cigar"},
+		TLDs: []string{"beer", "wine", "vodka"}},
+	{Category: "Dating", Keywords: []string{"dating", "singles", "match", "romance"}, TLDs: []string{"dating"}},
+	{Category: "Social Networking", Keywords: []string{"social", "community", "forum", "friends"},
+		TLDs: []string{"social", "community", "forum"}},
+	{Category: "Web-based Email", Keywords: []string{"mail", "webmail", "email", "inbox"}, TLDs: []string{"email", "mail"}},
+	{Category: "Computer and Internet Info", Keywords: []string{"tech", "software", "cloud", "hosting", "developer",
+		"computer", "internet", "server"}, TLDs: []string{"tech", "software", "cloud", "dev", "app"}},
+	{Category: "Religion", Keywords: []string{"church", "bible", "mosque", "temple", "faith", "ministries"},
+		TLDs: []string{"church", "bible", "faith"}},
+	{Category: "Motor Vehicles", Keywords: []string{"auto", "cars", "motors", "motorcycle", "dealer"},
+		TLDs: []string{"auto", "cars", "car", "motorcycles"}},
+}
+
+// HeuristicClassifier guesses the categories of websites from their domain names using keyword dictionaries
+// and public suffix hints. It makes no network requests, the guesses have low confidence.
+type HeuristicClassifier struct {
+	list *publicsuffix.List
+
+	names      map[int]string
+	keywords   map[string][]int
+	maxKeyword int
+	tlds       map[string][]int
+	unresolved []string
+}
+
+// NewHeuristicClassifier creates HeuristicClassifier for the category directory returned by GetAllCategories.
+// DefaultHeuristicRules are used if no rules are specified.
+func NewHeuristicClassifier(categories []CategoryItem, rules ...HeuristicRule) *HeuristicClassifier {
+	if len(rules) == 0 {
+		rules = DefaultHeuristicRules
+	}
+
+	c := &HeuristicClassifier{
+		list:     publicsuffix.Default(),
+		names:    make(map[int]string, len(categories)),
+		keywords: make(map[string][]int),
+		tlds:     make(map[string][]int),
+	}
+
+	ids := make(map[string]int, len(categories))
+
+	for _, category := range categories {
+		ids[strings.ToLower(category.Name)] = category.ID
+		c.names[category.ID] = category.Name
+	}
+
+	for _, rule := range rules {
+		id, ok := ids[strings.ToLower(rule.Category)]
+		if !ok {
+			c.unresolved = append(c.unresolved, rule.Category)
+
+			continue
+		}
+
+		for _, keyword := range rule.Keywords {
+			keyword = strings.ToLower(keyword)
+			c.keywords[keyword] = appendID(c.keywords[keyword], id)
+
+			if len(keyword) > c.maxKeyword {
+				c.maxKeyword = len(keyword)
+			}
+		}
+
+		for _, tld := range rule.TLDs {
+			tld = strings.ToLower(tld)
+			c.tlds[tld] = appendID(c.tlds[tld], id)
+		}
+	}
+
+	return c
+}
+
+// Unresolved returns the category names of the rules that are missing from the directory.
+func (c *HeuristicClassifier) Unresolved() []string {
+	return append([]string(nil), c.unresolved...)
+}
+
+// Classify returns the guessed categories of the domain name with Source set to SourceHeuristic.
+// The categories are sorted by confidence, the website is never reported as responded.
+func (c *HeuristicClassifier) Classify(domainName string) *WCategorizationResponse {
+	resp := &WCategorizationResponse{
+		DomainName: domainName,
+		Categories: []Category{},
+		Source:     SourceHeuristic,
+	}
+
+	name := strings.TrimSuffix(strings.ToLower(domainName), ".")
+
+	suffix, err := c.list.PublicSuffix(name)
+	if err != nil {
+		return resp
+	}
+
+	scores := make(map[int]float64)
+
+	for _, id := range c.tldHints(suffix) {
+		scores[id] = heuristicTLDConfidence
+	}
+
+	hits := make(map[int]int)
+
+	for _, label := range strings.Split(strings.TrimSuffix(strings.TrimSuffix(name, suffix), "."), ".") {
+		if label == "www" {
+			continue
+		}
+
+		for _, token := range strings.FieldsFunc(label, func(r rune) bool { return r < 'a' || r > 'z' }) {
+			for _, id := range c.segment(token) {
+				hits[id]++
+			}
+		}
+	}
+
+	for id, n := range hits {
+		score := heuristicKeywordConfidence + heuristicKeywordBonus*float64(n-1)
+		if score > scores[id] {
+			scores[id] = score
+		}
+	}
+
+	for id, score := range scores {
+		if score > HeuristicMaxConfidence {
+			score = HeuristicMaxConfidence
+		}
+
+		resp.Categories = append(resp.Categories, Category{ID: id, Name: c.names[id], Confidence: score})
+	}
+
+	sort.Slice(resp.Categories, func(i, j int) bool {
+		if resp.Categories[i].Confidence != resp.Categories[j].Confidence {
+			return resp.Categories[i].Confidence > resp.Categories[j].Confidence
+		}
+
+		return resp.Categories[i].ID < resp.Categories[j].ID
+	})
+
+	return resp
+}
+
+// tldHints returns the categories hinted by the public suffix or any of its labels, e.g. gov in service.gov.uk.
+func (c *HeuristicClassifier) tldHints(suffix string) []int {
+	var ids []int
+
+	for _, tld := range append([]string{suffix}, strings.Split(suffix, ".")...) {
+		for _, id := range c.tlds[tld] {
+			ids = appendID(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// segment splits the token into keywords taking the longest keyword at every position.
+// It returns the categories of the found keywords, once per keyword occurrence.
+func (c *HeuristicClassifier) segment(token string) []int {
+	var ids []int
+
+	for i := 0; i < len(token); {
+		matched := 0
+
+		for l := c.maxKeyword; l > 0; l-- {
+			if i+l > len(token) {
+				continue
+			}
+
+			if l < minSubstringKeyword && (i != 0 || l != len(token)) {
+				continue
+			}
+
+			if found, ok := c.keywords[token[i:i+l]]; ok {
+				ids = append(ids, found...)
+				matched = l
+
+				break
+			}
+		}
+
+		if matched == 0 {
+			i++
+		} else {
+			i += matched
+		}
+	}
+
+	return ids
+}
+
+// appendID appends the id unless the list contains it already.
+func appendID(ids []int, id int) []int {
+	if containsID(ids, id) {
+		return ids
+	}
+
+	return append(ids, id)
+}
+
+// HeuristicFallback answers Get requests with HeuristicClassifier when the API cannot be used:
+// it is unreachable, rate limited, failing, the budget or the API keys are exhausted, or the fallback
+// is switched to the offline mode. It is safe for concurrent use.
+type HeuristicFallback struct {
+	// Classifier guesses the categories.
+	Classifier *HeuristicClassifier
+
+	offline int32
+}
+
+// SetOffline switches the offline mode. In the offline mode the API is not requested at all.
+func (f *HeuristicFallback) SetOffline(offline bool) {
+	var v int32
+	if offline {
+		v = 1
+	}
+
+	atomic.StoreInt32(&f.offline, v)
+}
+
+// Offline reports whether the offline mode is on.
+func (f *HeuristicFallback) Offline() bool {
+	return atomic.LoadInt32(&f.offline) == 1
+}
+
+// unavailable reports whether the request failed because the API cannot be used: the API did not answer
+// in time, the request has not reached it or it answered with the 429 or 5xx status code whatever the body is.
+// Canceled requests never fall back.
+func (f *HeuristicFallback) unavailable(ctx context.Context, err error, resp *Response) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, ErrBudgetExceeded), errors.Is(err, ErrAPIKeysExhausted):
+		return true
+	case errors.Is(err, context.Canceled), errors.Is(ctx.Err(), context.Canceled):
+		return false
+	case errors.Is(err, context.DeadlineExceeded):
+		// The classification is local, so it still answers when the deadline has passed.
+		return true
+	case resp != nil && resp.Response != nil && retryableStatus(resp.Response.StatusCode):
+		return true
+	}
+
+	return retryable(context.Background(), err)
+}
+
+// WithHeuristicFallback returns the Decorator that answers Get and GetRaw requests with the fallback
+// classifier when the API cannot be used. Get returns nil Response for guessed results, GetRaw returns
+// the JSON encoded response with the status code 200.
+func WithHeuristicFallback(fallback *HeuristicFallback) Decorator {
+	return func(service WCategorizationService) WCategorizationService {
+		return &heuristicService{WCategorizationService: service, fallback: fallback}
+	}
+}
+
+// heuristicService is the WCategorizationService that falls back to HeuristicClassifier.
+type heuristicService struct {
+	WCategorizationService
+
+	fallback *HeuristicFallback
+}
+
+// Get returns the API response or the guessed categories.
+func (s *heuristicService) Get(ctx context.Context, domainName string, opts ...Option) (
+	*WCategorizationResponse, *Response, error) {
+	if domainName != "" && s.fallback.Offline() {
+		return s.fallback.Classifier.Classify(domainName), nil, nil
+	}
+
+	wCategorizationResp, resp, err := s.WCategorizationService.Get(ctx, domainName, opts...)
+	if s.fallback.unavailable(ctx, err, resp) {
+		return s.fallback.Classifier.Classify(domainName), nil, nil
+	}
+
+	return wCategorizationResp, resp, err
+}
+
+// GetRaw returns the API response or the JSON encoded guessed categories.
+func (s *heuristicService) GetRaw(ctx context.Context, domainName string, opts ...Option) (*Response, error) {
+	if domainName != "" && s.fallback.Offline() {
+		if requestedOutputFormat(opts) == OutputFormatXML {
+			return nil, &ArgError{"outputFormat", "must be " + string(OutputFormatJSON) + " in the offline mode"}
+		}
+
+		return syntheticResponse(s.fallback.Classifier.Classify(domainName))
+	}
+
+	resp, err := s.WCategorizationService.GetRaw(ctx, domainName, opts...)
+	if requestedOutputFormat(opts) == OutputFormatJSON && s.fallback.unavailable(ctx, err, resp) {
+		return syntheticResponse(s.fallback.Classifier.Classify(domainName))
+	}
+
+	return resp, err
+}
@@ -0,0 +1,251 @@
+package websitecategorization
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testDirectory is the sample of the category directory.
+var testDirectory = []CategoryItem{
+	{ID: 1, Name: "Gambling"},
+	{ID: 2, Name: "Educational Institutions"},
+	{ID: 3, Name: "Shopping"},
+	{ID: 4, Name: "Government"},
+	{ID: 5, Name: "Financial Services"},
+}
+
+// TestHeuristicClassifier tests the categories guessed from domain names.
+func TestHeuristicClassifier(t *testing.T) {
+	classifier := NewHeuristicClassifier(testDirectory)
+
+	tests := []struct {
+		domainName string
+		want       []Category
+	}{
+		{
+			domainName: "bestcasinobonus.com",
+			want:       []Category{{ID: 1, Name: "Gambling", Confidence: 0.3}},
+		},
+		{
+			domainName: "www.casino-poker-slots.net",
+			want:       []Category{{ID: 1, Name: "Gambling", Confidence: 0.4}},
+		},
+		{
+			domainName: "example.edu",
+			want:       []Category{{ID: 2, Name: "Educational Institutions", Confidence: 0.4}},
+		},
+		{
+			domainName: "cs.example.ac.uk",
+			want:       []Category{{ID: 2, Name: "Educational Institutions", Confidence: 0.4}},
+		},
+		{
+			domainName: "tax.service.gov.uk",
+			want:       []Category{{ID: 4, Name: "Government", Confidence: 0.4}},
+		},
+		{
+			domainName: "bankshop.store",
+			want: []Category{
+				{ID: 3, Name: "Shopping", Confidence: 0.4},
+				{ID: 5, Name: "Financial Services", Confidence: 0.3},
+			},
+		},
+		{
+			// Short keywords match whole tokens only.
+			domainName: "alphabet.com",
+			want:       []Category{},
+		},
+		{
+			domainName: "bet.example.com",
+			want:       []Category{{ID: 1, Name: "Gambling", Confidence: 0.3}},
+		},
+		{
+			domainName: "example.com",
+			want:       []Category{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.domainName, func(t *testing.T) {
+			got := classifier.Classify(tt.domainName)
+
+			if got.Source != SourceHeuristic || got.WebsiteResponded || got.DomainName != tt.domainName {
+				t.Errorf("Classify() = %+v", got)
+			}
+
+			if !reflect.DeepEqual(got.Categories, tt.want) {
+				t.Errorf("Classify() categories = %v, want %v", got.Categories, tt.want)
+			}
+
+			for _, category := range got.Categories {
+				if category.Confidence > HeuristicMaxConfidence {
+					t.Errorf("confidence %v exceeds %v", category.Confidence, HeuristicMaxConfidence)
+				}
+			}
+		})
+	}
+
+	if unresolved := NewHeuristicClassifier(testDirectory, HeuristicRule{Category: "Unknown"}).Unresolved(); len(unresolved) != 1 {
+		t.Errorf("Unresolved() = %v, want [Unknown]", unresolved)
+	}
+}
+
+// TestWithHeuristicFallback tests when the guesses replace API results.
+func TestWithHeuristicFallback(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		offline    bool
+		wantSource ResultSource
+		wantErr    bool
+		wantCalls  int
+	}{
+		{
+			name:      "API available",
+			wantCalls: 1,
+		},
+		{
+			name:       "API failing",
+			err:        &ErrorResponse{Response: &http.Response{StatusCode: http.StatusServiceUnavailable}},
+			wantSource: SourceHeuristic,
+			wantCalls:  1,
+		},
+		{
+			name:       "budget exceeded",
+			err:        &BudgetExceededError{Scope: BudgetScopeDaily, Limit: 10},
+			wantSource: SourceHeuristic,
+			wantCalls:  1,
+		},
+		{
+			name:      "invalid argument",
+			err:       &ArgError{"minConfidence", "must be between 0.00 and 1.00"},
+			wantErr:   true,
+			wantCalls: 1,
+		},
+		{
+			name:       "offline",
+			offline:    true,
+			wantSource: SourceHeuristic,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeService{errs: []error{tt.err, tt.err}}
+
+			fallback := &HeuristicFallback{Classifier: NewHeuristicClassifier(testDirectory)}
+			fallback.SetOffline(tt.offline)
+
+			service := Decorate(fake, WithHeuristicFallback(fallback))
+
+			resp, _, err := service.Get(context.Background(), "casino.example")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err == nil && resp.Source != tt.wantSource {
+				t.Errorf("Get() source = %q, want %q", resp.Source, tt.wantSource)
+			}
+
+			raw, err := service.GetRaw(context.Background(), "casino.example")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetRaw() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantSource != "" {
+				var decoded WCategorizationResponse
+				if err = json.Unmarshal(raw.Body, &decoded); err != nil || decoded.Source != tt.wantSource {
+					t.Errorf("GetRaw() = %s, error %v", raw.Body, err)
+				}
+			}
+
+			if len(fake.calls) != tt.wantCalls*2 {
+				t.Errorf("calls = %v, want %d", fake.calls, tt.wantCalls*2)
+			}
+		})
+	}
+
+	fallback := &HeuristicFallback{Classifier: NewHeuristicClassifier(testDirectory)}
+	fallback.SetOffline(true)
+
+	var argErr *ArgError
+	if _, err := Decorate(&fakeService{}, WithHeuristicFallback(fallback)).GetRaw(context.Background(), "casino.example",
+		OptionOutputFormat(OutputFormatXML)); !errors.As(err, &argErr) {
+		t.Errorf("GetRaw() with XML error = %v, want ArgError", err)
+	}
+}
+
+// TestWithHeuristicFallbackAPIDown tests the fallback when the API answers with error pages or does not answer in time.
+func TestWithHeuristicFallbackAPIDown(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("domainName") == "slow-casino.example" {
+			select {
+			case <-req.Context().Done():
+			case <-time.After(time.Second):
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("<html><body>503 Service Unavailable</body></html>"))
+	}))
+	defer server.Close()
+
+	apiURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewClient(apiKey, ClientParams{
+		HTTPClient:             server.Client(),
+		WCategorizationBaseURL: apiURL,
+		Decorators: []Decorator{
+			WithHeuristicFallback(&HeuristicFallback{Classifier: NewHeuristicClassifier(testDirectory)}),
+		},
+	})
+
+	tests := []struct {
+		name       string
+		domainName string
+		timeout    time.Duration
+	}{
+		{
+			name:       "error page",
+			domainName: "casino.example",
+			timeout:    time.Second,
+		},
+		{
+			name:       "timeout",
+			domainName: "slow-casino.example",
+			timeout:    20 * time.Millisecond,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			resp, _, err := client.Get(ctx, tt.domainName)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+
+			if resp.Source != SourceHeuristic || len(resp.Categories) == 0 || resp.Categories[0].ID != 1 {
+				t.Errorf("Get() = %+v, want the guessed Gambling category", resp)
+			}
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, _, err = client.Get(ctx, "casino.example"); err == nil {
+		t.Error("Get() with canceled context must fail")
+	}
+}
@@ -0,0 +1,370 @@
+package websitecategorization
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// HistoryEntry is the categorization result of a domain observed during a period of time.
+type HistoryEntry struct {
+	// Time is when the result was observed first.
+	Time time.Time `json:"time"`
+
+	// LastSeen is when the identical result was observed last.
+	LastSeen time.Time `json:"lastSeen"`
+
+	// Result is the categorization result.
+	Result *WCategorizationResponse `json:"result"`
+}
+
+// historyLine is the line of the history file. A line without the result confirms the previous result
+// of the domain at the time.
+type historyLine struct {
+	DomainName string                   `json:"domainName"`
+	Time       time.Time                `json:"time"`
+	LastSeen   *time.Time               `json:"lastSeen,omitempty"`
+	Result     *WCategorizationResponse `json:"result,omitempty"`
+}
+
+// HistoryStore keeps every categorization result of every domain with the time it was observed.
+// Identical consecutive results of a domain are stored once with the period they were observed.
+// The history is kept in memory and appended to the JSON Lines file, Compact rewrites the file
+// without the repeated observations. It implements MonitorStore and is safe for concurrent use.
+type HistoryStore struct {
+	mu sync.RWMutex
+
+	path string
+	file *os.File
+
+	domains map[string][]HistoryEntry
+}
+
+// OpenHistoryStore opens the history file creating it if it does not exist. The history is not persisted
+// if the path is empty.
+func OpenHistoryStore(path string) (*HistoryStore, error) {
+	s := &HistoryStore{path: path, domains: make(map[string][]HistoryEntry)}
+
+	if path == "" {
+		return s, nil
+	}
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// load reads the history file.
+func (s *HistoryStore) load() error {
+	file, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+
+	for n := 1; scanner.Scan(); n++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var line historyLine
+		if err = json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return fmt.Errorf("cannot parse history line %d: %w", n, err)
+		}
+
+		if line.Result == nil {
+			s.confirm(line.DomainName, line.Time)
+
+			continue
+		}
+
+		s.add(line.DomainName, line.Result, line.Time)
+
+		if line.LastSeen != nil {
+			s.confirm(line.DomainName, *line.LastSeen)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// open opens the history file for appending.
+func (s *HistoryStore) open() (err error) {
+	s.file, err = os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+
+	return err
+}
+
+// add adds the result to the history. It reports whether the result has to be written to the file, that is
+// it does not just confirm the last result, see mergeHistory.
+func (s *HistoryStore) add(domainName string, resp *WCategorizationResponse, observed time.Time) bool {
+	entries, confirmed := mergeHistory(s.domains[domainName], resp, observed)
+	s.domains[domainName] = entries
+
+	return !confirmed
+}
+
+// mergeHistory returns the copy of the entries with the result observed at the time. The result extends
+// the period of an identical neighboring entry instead of being added, so identical consecutive results
+// are stored once even if they are observed out of order. It reports whether the last entry is confirmed.
+func mergeHistory(entries []HistoryEntry, resp *WCategorizationResponse, observed time.Time) ([]HistoryEntry, bool) {
+	entries = append([]HistoryEntry(nil), entries...)
+
+	i := sort.Search(len(entries), func(i int) bool { return entries[i].Time.After(observed) })
+
+	switch {
+	case i > 0 && identical(entries[i-1].Result, resp):
+		if observed.After(entries[i-1].LastSeen) {
+			entries[i-1].LastSeen = observed
+		}
+
+		return entries, i == len(entries)
+	case i < len(entries) && identical(entries[i].Result, resp):
+		// The result was observed before the identical next one.
+		entries[i].Time = observed
+
+		return entries, false
+	}
+
+	entries = append(entries, HistoryEntry{})
+	copy(entries[i+1:], entries[i:])
+	entries[i] = HistoryEntry{Time: observed, LastSeen: observed, Result: resp.clone()}
+
+	return entries, false
+}
+
+// confirm extends the period of the last result of the domain.
+func (s *HistoryStore) confirm(domainName string, observed time.Time) {
+	entries := s.domains[domainName]
+	if len(entries) > 0 && observed.After(entries[len(entries)-1].LastSeen) {
+		entries[len(entries)-1].LastSeen = observed
+	}
+}
+
+// identical reports whether the results have no differences, see Diff.
+func identical(a, b *WCategorizationResponse) bool {
+	return len(Diff(a, b, 0)) == 0
+}
+
+// Record adds the result of the domain observed at the time to the history.
+func (s *HistoryStore) Record(domainName string, resp *WCategorizationResponse, observed time.Time) error {
+	if resp == nil {
+		return &ArgError{"resp", "can not be nil"}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	line := historyLine{DomainName: domainName, Time: observed.UTC()}
+
+	entries, confirmed := mergeHistory(s.domains[domainName], resp, line.Time)
+	if !confirmed {
+		line.Result = resp
+	}
+
+	// The history in memory is changed only when it is persisted.
+	if err := s.append(line); err != nil {
+		return err
+	}
+
+	s.domains[domainName] = entries
+
+	return nil
+}
+
+// errHistoryClosed is returned when the closed HistoryStore is changed.
+var errHistoryClosed = errors.New("history store is closed")
+
+// append writes the line to the history file.
+func (s *HistoryStore) append(line historyLine) error {
+	if s.path == "" {
+		return nil
+	}
+
+	if s.file == nil {
+		return errHistoryClosed
+	}
+
+	data, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+
+	if _, err = s.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("cannot write history: %w", err)
+	}
+
+	return nil
+}
+
+// Load returns the last result of the domain or nil if there is none.
+func (s *HistoryStore) Load(domainName string) (*WCategorizationResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := s.domains[domainName]
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	return entries[len(entries)-1].Result.clone(), nil
+}
+
+// Save records the result, it is the same as Record.
+func (s *HistoryStore) Save(domainName string, resp *WCategorizationResponse, checked time.Time) error {
+	return s.Record(domainName, resp, checked)
+}
+
+// AsOf returns the result of the domain in effect at the time: the last one observed at or before the time.
+func (s *HistoryStore) AsOf(domainName string, at time.Time) (*WCategorizationResponse, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := s.domains[domainName]
+
+	i := sort.Search(len(entries), func(i int) bool { return entries[i].Time.After(at) })
+	if i == 0 {
+		return nil, false
+	}
+
+	return entries[i-1].Result.clone(), true
+}
+
+// History returns the results of the domain in the order they were observed.
+func (s *HistoryStore) History(domainName string) []HistoryEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := make([]HistoryEntry, len(s.domains[domainName]))
+	for i, entry := range s.domains[domainName] {
+		entry.Result = entry.Result.clone()
+		entries[i] = entry
+	}
+
+	return entries
+}
+
+// ChangedSince returns the sorted names of the domains whose result changed at or after the time,
+// e.g. ChangedSince(time.Now().AddDate(0, 0, -7)) for the last week. Only the changes of the kinds
+// are taken into account, categories added or removed by default.
+func (s *HistoryStore) ChangedSince(since time.Time, kinds ...ChangeKind) []string {
+	if len(kinds) == 0 {
+		kinds = []ChangeKind{ChangeCategoryAdded, ChangeCategoryRemoved}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var changed []string
+
+	for domainName, entries := range s.domains {
+		if historyChanged(entries, since, kinds) {
+			changed = append(changed, domainName)
+		}
+	}
+
+	sort.Strings(changed)
+
+	return changed
+}
+
+// historyChanged reports whether the entries have a change of the kinds at or after the time.
+func historyChanged(entries []HistoryEntry, since time.Time, kinds []ChangeKind) bool {
+	for i := len(entries) - 1; i > 0 && !entries[i].Time.Before(since); i-- {
+		for _, change := range Diff(entries[i-1].Result, entries[i].Result, 0) {
+			for _, kind := range kinds {
+				if change.Kind == kind {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// Compact rewrites the history file with one line per stored result.
+func (s *HistoryStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.path == "" {
+		return nil
+	}
+
+	if s.file == nil {
+		return errHistoryClosed
+	}
+
+	domains := make([]string, 0, len(s.domains))
+	for domainName := range s.domains {
+		domains = append(domains, domainName)
+	}
+
+	sort.Strings(domains)
+
+	var buf bytes.Buffer
+
+	encoder := json.NewEncoder(&buf)
+
+	for _, domainName := range domains {
+		for _, entry := range s.domains[domainName] {
+			entry := entry
+
+			line := historyLine{DomainName: domainName, Time: entry.Time, Result: entry.Result}
+			if entry.LastSeen.After(entry.Time) {
+				line.LastSeen = &entry.LastSeen
+			}
+
+			if err := encoder.Encode(line); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(s.path, buf.Bytes(), 0o644); err != nil {
+		_ = s.open()
+
+		return fmt.Errorf("cannot compact history: %w", err)
+	}
+
+	return s.open()
+}
+
+// Close closes the history file.
+func (s *HistoryStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+
+	err := s.file.Close()
+	s.file = nil
+
+	return err
+}
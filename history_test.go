@@ -0,0 +1,208 @@
+package websitecategorization
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestHistoryStore tests the as-of queries and the compaction of the history.
+func TestHistoryStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+
+	store, err := OpenHistoryStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day := func(d int) time.Time { return time.Date(2021, 1, d, 12, 0, 0, 0, time.UTC) }
+
+	shop := &WCategorizationResponse{DomainName: "partner.com", Categories: []Category{{ID: 3, Confidence: 0.9}}}
+	casino := &WCategorizationResponse{DomainName: "partner.com", Categories: []Category{{ID: 1, Confidence: 0.8}}}
+	moved := &WCategorizationResponse{DomainName: "stable.com", Categories: []Category{{ID: 5, Confidence: 0.9}},
+		AS: &AS{ASN: 1}}
+
+	records := []struct {
+		domainName string
+		resp       *WCategorizationResponse
+		day        int
+	}{
+		{"partner.com", shop, 1},
+		{"partner.com", shop, 2},
+		{"partner.com", shop, 3},
+		{"partner.com", casino, 10},
+		{"partner.com", casino, 11},
+		{"stable.com", moved, 1},
+		{"stable.com", &WCategorizationResponse{DomainName: "stable.com", Categories: moved.Categories}, 10},
+	}
+	for _, r := range records {
+		if err = store.Record(r.domainName, r.resp, day(r.day)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check := func(t *testing.T, store *HistoryStore) {
+		t.Helper()
+
+		history := store.History("partner.com")
+		if len(history) != 2 || !history[0].Time.Equal(day(1)) || !history[0].LastSeen.Equal(day(3)) ||
+			!history[1].Time.Equal(day(10)) || history[1].LastSeen.Before(day(11)) {
+			t.Errorf("History() = %+v", history)
+		}
+
+		tests := []struct {
+			at     time.Time
+			want   int
+			wantOK bool
+		}{
+			{at: day(1).Add(-time.Hour)},
+			{at: day(1), want: 3, wantOK: true},
+			{at: day(5), want: 3, wantOK: true},
+			{at: day(10), want: 1, wantOK: true},
+			{at: day(30), want: 1, wantOK: true},
+		}
+		for _, tt := range tests {
+			got, ok := store.AsOf("partner.com", tt.at)
+			if ok != tt.wantOK || ok && got.Categories[0].ID != tt.want {
+				t.Errorf("AsOf(%v) = %+v, %v, want category %d", tt.at, got, ok, tt.want)
+			}
+		}
+
+		if got := store.ChangedSince(day(4)); !reflect.DeepEqual(got, []string{"partner.com"}) {
+			t.Errorf("ChangedSince() = %v, want [partner.com]", got)
+		}
+
+		if got := store.ChangedSince(day(4), ChangeAS); !reflect.DeepEqual(got, []string{"stable.com"}) {
+			t.Errorf("ChangedSince(ChangeAS) = %v, want [stable.com]", got)
+		}
+
+		if got := store.ChangedSince(day(11)); len(got) != 0 {
+			t.Errorf("ChangedSince() = %v, want no domains", got)
+		}
+
+		if last, err := store.Load("partner.com"); err != nil || last.Categories[0].ID != 1 {
+			t.Errorf("Load() = %+v, %v", last, err)
+		}
+	}
+
+	check(t, store)
+
+	if err = store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = store.Record("partner.com", shop, day(12)); !errors.Is(err, errHistoryClosed) {
+		t.Errorf("Record() after Close error = %v", err)
+	}
+
+	// The result that is not written is not kept in memory either.
+	check(t, store)
+
+	t.Run("reopened", func(t *testing.T) {
+		store, err := OpenHistoryStore(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer store.Close()
+
+		check(t, store)
+
+		before, _ := os.ReadFile(path)
+
+		if err = store.Compact(); err != nil {
+			t.Fatal(err)
+		}
+
+		after, _ := os.ReadFile(path)
+		if lines := bytes.Count(after, []byte("\n")); lines != 4 || len(after) >= len(before) {
+			t.Errorf("compacted file has %d lines:\n%s", lines, after)
+		}
+
+		check(t, store)
+
+		if err = store.Record("partner.com", casino, day(12)); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("compacted", func(t *testing.T) {
+		store, err := OpenHistoryStore(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer store.Close()
+
+		check(t, store)
+
+		if history := store.History("partner.com"); !history[1].LastSeen.Equal(day(12)) {
+			t.Errorf("last seen = %v, want %v", history[1].LastSeen, day(12))
+		}
+	})
+}
+
+// TestHistoryStoreOutOfOrder tests that the results observed out of order are merged with identical neighbors.
+func TestHistoryStoreOutOfOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+
+	store, err := OpenHistoryStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day := func(d int) time.Time { return time.Date(2021, 1, d, 12, 0, 0, 0, time.UTC) }
+
+	shop := &WCategorizationResponse{DomainName: "partner.com", Categories: []Category{{ID: 3, Confidence: 0.9}}}
+	casino := &WCategorizationResponse{DomainName: "partner.com", Categories: []Category{{ID: 1, Confidence: 0.8}}}
+
+	records := []struct {
+		resp *WCategorizationResponse
+		day  int
+	}{
+		{shop, 1},
+		{casino, 10},
+		{shop, 5},
+		{casino, 8},
+		{casino, 12},
+	}
+	for _, r := range records {
+		if err = store.Record("partner.com", r.resp, day(r.day)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check := func(t *testing.T, store *HistoryStore) {
+		t.Helper()
+
+		history := store.History("partner.com")
+		if len(history) != 2 || !history[0].Time.Equal(day(1)) || !history[0].LastSeen.Equal(day(5)) ||
+			!history[1].Time.Equal(day(8)) || !history[1].LastSeen.Equal(day(12)) {
+			t.Errorf("History() = %+v, want shop from day 1 to 5 and casino from day 8 to 12", history)
+		}
+
+		if got := store.ChangedSince(day(6)); !reflect.DeepEqual(got, []string{"partner.com"}) {
+			t.Errorf("ChangedSince() = %v, want [partner.com]", got)
+		}
+
+		if got := store.ChangedSince(day(9)); len(got) != 0 {
+			t.Errorf("ChangedSince() = %v, want no domains", got)
+		}
+	}
+
+	check(t, store)
+
+	if err = store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := OpenHistoryStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+
+	check(t, reopened)
+}
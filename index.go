@@ -0,0 +1,245 @@
+package websitecategorization
+
+import (
+	"errors"
+	"io"
+	"sort"
+	"sync"
+)
+
+// ResponseReader reads categorization results, e.g. JSONLReader or CSVReader.
+type ResponseReader interface {
+	// Read returns the next response. It returns io.EOF when there are no more responses.
+	Read() (*WCategorizationResponse, error)
+}
+
+// DomainConfidence is a domain with the confidence of a category.
+type DomainConfidence struct {
+	// DomainName is the domain name.
+	DomainName string `json:"domainName"`
+
+	// Confidence is the confidence of the category.
+	Confidence float64 `json:"confidence"`
+}
+
+// CategoryPair is the number of domains that have both categories.
+type CategoryPair struct {
+	// First is the category with the lower ID.
+	First int `json:"first"`
+
+	// Second is the category with the higher ID.
+	Second int `json:"second"`
+
+	// Count is the number of domains.
+	Count int `json:"count"`
+}
+
+// CategoryIndex is the in-memory index of categorization results by category. Adding the result of a domain
+// replaces its previous result, so the index can be updated as new results arrive. It is safe for concurrent use.
+type CategoryIndex struct {
+	mu sync.RWMutex
+
+	// domains are the indexed categories of the domains.
+	domains map[string][]Category
+
+	// categories are the confidences of the domains by category ID.
+	categories map[int]map[string]float64
+
+	// names are the last seen category names.
+	names map[int]string
+}
+
+// NewCategoryIndex creates empty CategoryIndex.
+func NewCategoryIndex() *CategoryIndex {
+	return &CategoryIndex{
+		domains:    make(map[string][]Category),
+		categories: make(map[int]map[string]float64),
+		names:      make(map[int]string),
+	}
+}
+
+// Add indexes the result replacing the previous result of the domain.
+func (x *CategoryIndex) Add(resp *WCategorizationResponse) {
+	if resp == nil {
+		return
+	}
+
+	categories := make([]Category, len(resp.Categories))
+	copy(categories, resp.Categories)
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.remove(resp.DomainName)
+
+	x.domains[resp.DomainName] = categories
+
+	for _, category := range categories {
+		domains, ok := x.categories[category.ID]
+		if !ok {
+			domains = make(map[string]float64)
+			x.categories[category.ID] = domains
+		}
+
+		domains[resp.DomainName] = category.Confidence
+
+		if category.Name != "" {
+			x.names[category.ID] = category.Name
+		}
+	}
+}
+
+// AddFrom indexes every result read from the reader. It returns the number of indexed results.
+func (x *CategoryIndex) AddFrom(r ResponseReader) (int, error) {
+	for n := 0; ; n++ {
+		resp, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return n, nil
+		}
+
+		if err != nil {
+			return n, err
+		}
+
+		x.Add(resp)
+	}
+}
+
+// Remove removes the domain from the index.
+func (x *CategoryIndex) Remove(domainName string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.remove(domainName)
+}
+
+// remove removes the domain from the index without locking.
+func (x *CategoryIndex) remove(domainName string) {
+	for _, category := range x.domains[domainName] {
+		domains := x.categories[category.ID]
+		delete(domains, domainName)
+
+		if len(domains) == 0 {
+			delete(x.categories, category.ID)
+		}
+	}
+
+	delete(x.domains, domainName)
+}
+
+// Len returns the number of indexed domains.
+func (x *CategoryIndex) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	return len(x.domains)
+}
+
+// Name returns the last indexed name of the category.
+func (x *CategoryIndex) Name(categoryID int) string {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	return x.names[categoryID]
+}
+
+// Domains returns the domains of the category with the confidence greater than or equal to minConfidence
+// sorted by confidence in descending order, domains with equal confidence are ordered by name.
+func (x *CategoryIndex) Domains(categoryID int, minConfidence float64) []DomainConfidence {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	var domains []DomainConfidence
+
+	for domainName, confidence := range x.categories[categoryID] {
+		if confidence >= minConfidence {
+			domains = append(domains, DomainConfidence{DomainName: domainName, Confidence: confidence})
+		}
+	}
+
+	sort.Slice(domains, func(i, j int) bool {
+		if domains[i].Confidence != domains[j].Confidence {
+			return domains[i].Confidence > domains[j].Confidence
+		}
+
+		return domains[i].DomainName < domains[j].DomainName
+	})
+
+	return domains
+}
+
+// Top returns at most n domains of the category with the highest confidence, see Domains.
+func (x *CategoryIndex) Top(categoryID, n int) []DomainConfidence {
+	domains := x.Domains(categoryID, 0)
+	if n >= 0 && len(domains) > n {
+		domains = domains[:n]
+	}
+
+	return domains
+}
+
+// Counts returns the number of domains of every category with the confidence greater than or equal
+// to minConfidence.
+func (x *CategoryIndex) Counts(minConfidence float64) map[int]int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	counts := make(map[int]int, len(x.categories))
+
+	for id, domains := range x.categories {
+		for _, confidence := range domains {
+			if confidence >= minConfidence {
+				counts[id]++
+			}
+		}
+	}
+
+	return counts
+}
+
+// CoOccurrence returns the number of domains for every pair of categories the domains have together,
+// taking into account the categories with the confidence greater than or equal to minConfidence.
+// The pairs are sorted by count in descending order, then by category IDs.
+func (x *CategoryIndex) CoOccurrence(minConfidence float64) []CategoryPair {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	counts := make(map[[2]int]int)
+
+	for _, categories := range x.domains {
+		var ids []int
+
+		for _, category := range categories {
+			if category.Confidence >= minConfidence {
+				ids = appendID(ids, category.ID)
+			}
+		}
+
+		sort.Ints(ids)
+
+		for i := range ids {
+			for j := i + 1; j < len(ids); j++ {
+				counts[[2]int{ids[i], ids[j]}]++
+			}
+		}
+	}
+
+	pairs := make([]CategoryPair, 0, len(counts))
+	for ids, count := range counts {
+		pairs = append(pairs, CategoryPair{First: ids[0], Second: ids[1], Count: count})
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].Count != pairs[j].Count {
+			return pairs[i].Count > pairs[j].Count
+		}
+
+		if pairs[i].First != pairs[j].First {
+			return pairs[i].First < pairs[j].First
+		}
+
+		return pairs[i].Second < pairs[j].Second
+	})
+
+	return pairs
+}
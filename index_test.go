@@ -0,0 +1,91 @@
+package websitecategorization
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestCategoryIndex tests the queries and the incremental updates of the index.
+func TestCategoryIndex(t *testing.T) {
+	input := `{"domainName":"casino.com","categories":[{"id":1,"name":"Gambling","confidence":0.9},{"id":3,"name":"Shopping","confidence":0.4}]}
+{"domainName":"poker.com","categories":[{"id":1,"name":"Gambling","confidence":0.7},{"id":2,"name":"Games","confidence":0.6}]}
+{"domainName":"bingo.com","categories":[{"id":1,"name":"Gambling","confidence":0.7},{"id":2,"name":"Games","confidence":0.8}]}
+
+{"domainName":"shop.com","categories":[{"id":3,"name":"Shopping","confidence":0.95}]}
+`
+
+	index := NewCategoryIndex()
+
+	n, err := index.AddFrom(NewJSONLReader(strings.NewReader(input)))
+	if err != nil || n != 4 || index.Len() != 4 {
+		t.Fatalf("AddFrom() = %d, %v, index has %d domains", n, err, index.Len())
+	}
+
+	tests := []struct {
+		name          string
+		categoryID    int
+		minConfidence float64
+		want          []DomainConfidence
+	}{
+		{
+			name:       "all domains",
+			categoryID: 1,
+			want: []DomainConfidence{
+				{DomainName: "casino.com", Confidence: 0.9},
+				{DomainName: "bingo.com", Confidence: 0.7},
+				{DomainName: "poker.com", Confidence: 0.7},
+			},
+		},
+		{
+			name:          "confident domains",
+			categoryID:    3,
+			minConfidence: 0.5,
+			want:          []DomainConfidence{{DomainName: "shop.com", Confidence: 0.95}},
+		},
+		{
+			name:       "unknown category",
+			categoryID: 99,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := index.Domains(tt.categoryID, tt.minConfidence); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Domains() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := index.Top(1, 2); len(got) != 2 || got[0].DomainName != "casino.com" {
+		t.Errorf("Top() = %v", got)
+	}
+
+	wantPairs := []CategoryPair{{First: 1, Second: 2, Count: 2}, {First: 1, Second: 3, Count: 1}}
+	if got := index.CoOccurrence(0); !reflect.DeepEqual(got, wantPairs) {
+		t.Errorf("CoOccurrence() = %v, want %v", got, wantPairs)
+	}
+
+	if got := index.CoOccurrence(0.5); !reflect.DeepEqual(got, wantPairs[:1]) {
+		t.Errorf("CoOccurrence(0.5) = %v, want %v", got, wantPairs[:1])
+	}
+
+	if got := index.Counts(0.5); !reflect.DeepEqual(got, map[int]int{1: 3, 2: 2, 3: 1}) {
+		t.Errorf("Counts() = %v", got)
+	}
+
+	// The new result replaces the previous one.
+	index.Add(&WCategorizationResponse{DomainName: "casino.com", Categories: []Category{{ID: 3, Confidence: 0.5}}})
+	index.Remove("poker.com")
+
+	if got := index.Domains(1, 0); !reflect.DeepEqual(got, []DomainConfidence{{DomainName: "bingo.com", Confidence: 0.7}}) {
+		t.Errorf("Domains() after update = %v", got)
+	}
+
+	if got := index.CoOccurrence(0); !reflect.DeepEqual(got, []CategoryPair{{First: 1, Second: 2, Count: 1}}) {
+		t.Errorf("CoOccurrence() after update = %v", got)
+	}
+
+	if index.Len() != 3 || index.Name(1) != "Gambling" {
+		t.Errorf("index has %d domains, category name %q", index.Len(), index.Name(1))
+	}
+}
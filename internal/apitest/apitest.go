@@ -0,0 +1,156 @@
+// Package apitest contains the fake Website Categorization API server shared by the tests of the command line tools.
+package apitest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	websitecategorization "github.com/whois-api-llc/website-categorization-go"
+)
+
+// UnreachableDomain is the domain name the server answers with the status code 500.
+const UnreachableDomain = "unreachable.test"
+
+// Server is the fake Website Categorization API server returning predefined categories. It filters
+// the categories by the minConfidence parameter as the API does and counts the requests.
+type Server struct {
+	*httptest.Server
+
+	categories map[string][]websitecategorization.Category
+	requests   int32
+
+	mu       sync.Mutex
+	apiKey   string
+	handlers map[string]http.HandlerFunc
+}
+
+// NewServer starts Server returning the categories of the domains. The server is closed when the test ends.
+func NewServer(t testing.TB, categories map[string][]websitecategorization.Category) *Server {
+	t.Helper()
+
+	s := &Server{
+		categories: categories,
+		handlers:   make(map[string]http.HandlerFunc),
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// RequireAPIKey makes the server reject the requests without the API key.
+func (s *Server) RequireAPIKey(apiKey string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.apiKey = apiKey
+}
+
+// Handle sets the handler answering the requests for the domain instead of the predefined categories.
+func (s *Server) Handle(domainName string, handler http.HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.handlers[domainName] = handler
+}
+
+// Requests returns the number of received requests.
+func (s *Server) Requests() int32 {
+	return atomic.LoadInt32(&s.requests)
+}
+
+// BaseURL returns the server URL with the path, e.g. "/api/v3".
+func (s *Server) BaseURL(t testing.TB, path string) *url.URL {
+	t.Helper()
+
+	baseURL, err := url.Parse(s.URL + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return baseURL
+}
+
+// NewClient returns the caching client requesting the server with the API key.
+func (s *Server) NewClient(t testing.TB, apiKey string) *websitecategorization.Client {
+	t.Helper()
+
+	return websitecategorization.NewClient(apiKey, websitecategorization.ClientParams{
+		HTTPClient:             s.Client(),
+		WCategorizationBaseURL: s.BaseURL(t, ""),
+		Cache:                  websitecategorization.NewMemoryCache(0, 0),
+	})
+}
+
+// serveHTTP answers the API request.
+func (s *Server) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	atomic.AddInt32(&s.requests, 1)
+
+	query := req.URL.Query()
+	domainName := query.Get("domainName")
+
+	s.mu.Lock()
+	apiKey := s.apiKey
+	handler := s.handlers[domainName]
+	s.mu.Unlock()
+
+	if apiKey != "" && query.Get("apiKey") != apiKey {
+		w.WriteHeader(http.StatusForbidden)
+		_ = json.NewEncoder(w).Encode(websitecategorization.ErrorMessage{
+			Code:    http.StatusForbidden,
+			Message: "Access restricted. Check the credits balance or enter the correct API key.",
+		})
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/categories"):
+		_ = json.NewEncoder(w).Encode(s.directory())
+	case handler != nil:
+		handler(w, req)
+	case domainName == UnreachableDomain:
+		w.WriteHeader(http.StatusInternalServerError)
+	default:
+		minConfidence, _ := strconv.ParseFloat(query.Get("minConfidence"), 64)
+
+		resp := &websitecategorization.WCategorizationResponse{
+			DomainName:       domainName,
+			Categories:       s.categories[domainName],
+			WebsiteResponded: true,
+		}
+
+		_ = json.NewEncoder(w).Encode(resp.WithMinConfidence(minConfidence))
+	}
+}
+
+// directory returns the categories of all domains sorted by ID.
+func (s *Server) directory() []websitecategorization.CategoryItem {
+	names := make(map[int]string)
+
+	for _, categories := range s.categories {
+		for _, category := range categories {
+			names[category.ID] = category.Name
+		}
+	}
+
+	directory := make([]websitecategorization.CategoryItem, 0, len(names))
+	for id, name := range names {
+		directory = append(directory, websitecategorization.CategoryItem{ID: id, Name: name})
+	}
+
+	sort.Slice(directory, func(i, j int) bool { return directory[i].ID < directory[j].ID })
+
+	return directory
+}
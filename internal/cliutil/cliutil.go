@@ -0,0 +1,297 @@
+// Package cliutil contains helpers shared by the command line tools.
+package cliutil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	websitecategorization "github.com/whois-api-llc/website-categorization-go"
+	"github.com/whois-api-llc/website-categorization-go/publicsuffix"
+)
+
+// APIKeyEnv is the environment variable holding the API key when it is not passed as a flag.
+const APIKeyEnv = "WCATEGORIZATION_API_KEY"
+
+// overridesReloadInterval is the period of checking the overrides file for changes.
+const overridesReloadInterval = 10 * time.Second
+
+// IDList is the flag.Value holding a comma-separated list of category IDs.
+type IDList []int
+
+var _ flag.Value = &IDList{}
+
+// String returns the comma-separated list of IDs.
+func (l *IDList) String() string {
+	ids := make([]string, 0, len(*l))
+	for _, id := range *l {
+		ids = append(ids, strconv.Itoa(id))
+	}
+
+	return strings.Join(ids, ",")
+}
+
+// Set appends comma-separated IDs to the list.
+func (l *IDList) Set(value string) error {
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		id, err := strconv.Atoi(field)
+		if err != nil {
+			return fmt.Errorf("invalid category ID %q", field)
+		}
+
+		*l = append(*l, id)
+	}
+
+	return nil
+}
+
+// ClientFlags holds the common flags used to create the API client.
+type ClientFlags struct {
+	APIKey   string
+	BaseURL  string
+	CacheTTL time.Duration
+	CacheMax int
+
+	BudgetDaily int
+	BudgetTotal int
+	BudgetState string
+
+	RegistrableDomain bool
+	PublicSuffixList  string
+	SubdomainFallback int
+	Overrides         string
+
+	HeuristicCategories string
+	Offline             bool
+
+	// NoCache disables the cache of categorization results for tools that cache raw responses themselves.
+	// It is not registered as a flag.
+	NoCache bool
+}
+
+// Register registers the client flags in the flag set.
+func (f *ClientFlags) Register(fs *flag.FlagSet) {
+	f.RegisterAPI(fs)
+	fs.DurationVar(&f.CacheTTL, "cache-ttl", 24*time.Hour, "lifetime of cached categorization results")
+	fs.IntVar(&f.CacheMax, "cache-size", 100000, "maximum number of cached categorization results")
+	fs.IntVar(&f.BudgetDaily, "budget-daily", 0, "maximum number of billable requests per day, 0 means no limit")
+	fs.IntVar(&f.BudgetTotal, "budget-total", 0, "maximum number of billable requests in total, 0 means no limit")
+	fs.StringVar(&f.BudgetState, "budget-state", "", "file to persist the budget counters in")
+	fs.BoolVar(&f.RegistrableDomain, "registrable-domain", false, "request registrable domains instead of full host names")
+	fs.StringVar(&f.PublicSuffixList, "public-suffix-list", "", "public_suffix_list.dat file used instead of the embedded one")
+	fs.StringVar(&f.Overrides, "overrides", "", "JSON file with categories of local domains, reloaded when it changes")
+	fs.StringVar(&f.HeuristicCategories, "heuristic-categories", "", "JSON file with the category directory used to guess categories when the API is unavailable")
+	fs.BoolVar(&f.Offline, "offline", false, "guess categories from domain names without calling the API, requires -heuristic-categories")
+	fs.IntVar(&f.SubdomainFallback, "subdomain-fallback", 0, "maximum number of parent domains looked up for uncategorized hosts, 0 disables the fallback")
+}
+
+// RegisterAPI registers only the flags of the API access, -api-key and -api-url, for the tools making
+// single requests. Other client flags keep their zero values.
+func (f *ClientFlags) RegisterAPI(fs *flag.FlagSet) {
+	fs.StringVar(&f.APIKey, "api-key", "", "comma-separated API keys used with failover, defaults to the "+APIKeyEnv+" environment variable")
+	fs.StringVar(&f.BaseURL, "api-url", "", "Website Categorization API base URL")
+}
+
+// NewClient creates the API client according to the flags, caching the results unless NoCache is set.
+// Background tasks, e.g. reloading the overrides, run until the context is canceled and report their
+// errors to the logger.
+// The reached budget thresholds are sent to the notifier if it is not nil, see WebhookFlags.NewNotifier.
+func (f *ClientFlags) NewClient(
+	ctx context.Context,
+	logger *log.Logger,
+	notifier *Notifier,
+) (*websitecategorization.Client, error) {
+	apiKey := f.APIKey
+	if apiKey == "" {
+		apiKey = os.Getenv(APIKeyEnv)
+	}
+
+	var keys []string
+
+	for _, key := range strings.Split(apiKey, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("API key is not specified")
+	}
+
+	var params websitecategorization.ClientParams
+
+	if !f.NoCache {
+		params.Cache = websitecategorization.NewMemoryCache(f.CacheTTL, f.CacheMax)
+	}
+
+	for _, key := range keys[1:] {
+		params.APIKeys = append(params.APIKeys, websitecategorization.APIKey{Key: key})
+	}
+
+	if f.BudgetDaily > 0 || f.BudgetTotal > 0 {
+		budget, err := websitecategorization.NewBudget(websitecategorization.BudgetLimits{
+			Daily: f.BudgetDaily,
+			Total: f.BudgetTotal,
+		}, f.BudgetState)
+		if err != nil {
+			return nil, err
+		}
+
+		if notifier != nil {
+			budget.OnThreshold(budgetThresholds, func(threshold websitecategorization.BudgetThreshold) {
+				notifier.Notify(websitecategorization.BudgetThresholdEvent(threshold))
+			})
+		}
+
+		params.Budget = budget
+	}
+
+	if f.Overrides != "" {
+		overrides, err := websitecategorization.LoadOverrides(f.Overrides)
+		if err != nil {
+			return nil, err
+		}
+
+		go overrides.Watch(ctx, overridesReloadInterval, func(err error) {
+			logger.Printf("cannot reload overrides: %v", err)
+		})
+
+		params.Decorators = append(params.Decorators, websitecategorization.WithOverrides(overrides))
+	}
+
+	if f.HeuristicCategories != "" {
+		fallback, err := loadHeuristicFallback(f.HeuristicCategories, logger)
+		if err != nil {
+			return nil, err
+		}
+
+		fallback.SetOffline(f.Offline)
+
+		params.Decorators = append(params.Decorators, websitecategorization.WithHeuristicFallback(fallback))
+	} else if f.Offline {
+		return nil, errors.New("offline mode requires -heuristic-categories")
+	}
+
+	var list *publicsuffix.List
+
+	if f.PublicSuffixList != "" {
+		var err error
+
+		if list, err = publicsuffix.Load(f.PublicSuffixList); err != nil {
+			return nil, fmt.Errorf("cannot load public suffix list: %w", err)
+		}
+	}
+
+	if f.RegistrableDomain {
+		params.Decorators = append(params.Decorators,
+			websitecategorization.WithDomainReduction(websitecategorization.DomainReduction{List: list}))
+	}
+
+	if f.SubdomainFallback > 0 {
+		params.Decorators = append(params.Decorators,
+			websitecategorization.WithSubdomainFallback(websitecategorization.SubdomainFallback{
+				MaxLookups: f.SubdomainFallback,
+				List:       list,
+			}))
+	}
+
+	if f.BaseURL != "" {
+		baseURL, err := url.Parse(f.BaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid API URL: %w", err)
+		}
+
+		params.WCategorizationBaseURL = baseURL
+	}
+
+	return websitecategorization.NewClient(keys[0], params), nil
+}
+
+// loadHeuristicFallback creates the heuristic fallback for the category directory saved in the JSON file.
+func loadHeuristicFallback(path string, logger *log.Logger) (*websitecategorization.HeuristicFallback, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var categories []websitecategorization.CategoryItem
+	if err = json.Unmarshal(data, &categories); err != nil {
+		return nil, fmt.Errorf("cannot parse categories: %w", err)
+	}
+
+	classifier := websitecategorization.NewHeuristicClassifier(categories)
+	if unresolved := classifier.Unresolved(); len(unresolved) > 0 {
+		logger.Printf("heuristic rules ignored for unknown categories: %s", strings.Join(unresolved, ", "))
+	}
+
+	return &websitecategorization.HeuristicFallback{Classifier: classifier}, nil
+}
+
+// PolicyFlags holds the flags describing the category policy.
+type PolicyFlags struct {
+	File               string
+	Deny               IDList
+	Allow              IDList
+	MinConfidence      float64
+	BlockUncategorized bool
+
+	HeuristicMinConfidence float64
+}
+
+// Register registers the policy flags in the flag set.
+func (f *PolicyFlags) Register(fs *flag.FlagSet) {
+	fs.StringVar(&f.File, "policy", "", "JSON file with the category policy, overrides other policy flags")
+	fs.Var(&f.Deny, "deny", "comma-separated category IDs to block")
+	fs.Var(&f.Allow, "allow", "comma-separated category IDs to allow even if they match -deny")
+	fs.Float64Var(&f.MinConfidence, "min-confidence", 0, "minimum confidence of a category to be taken into account")
+	fs.BoolVar(&f.BlockUncategorized, "block-uncategorized", false, "block websites without categories")
+	fs.Float64Var(&f.HeuristicMinConfidence, "heuristic-min-confidence", 0, "minimum confidence of a category "+
+		"guessed from the domain name, -min-confidence is used if zero; guesses are at most "+
+		strconv.FormatFloat(websitecategorization.HeuristicMaxConfidence, 'g', -1, 64))
+}
+
+// Policy returns the policy loaded from the file or built from the flags.
+func (f *PolicyFlags) Policy() (websitecategorization.Policy, error) {
+	if f.HeuristicMinConfidence > websitecategorization.HeuristicMaxConfidence {
+		// Guessed categories would never match.
+		return websitecategorization.Policy{}, fmt.Errorf("-heuristic-min-confidence must not be greater than %g",
+			websitecategorization.HeuristicMaxConfidence)
+	}
+
+	if f.File == "" {
+		return websitecategorization.Policy{
+			Deny:               f.Deny,
+			Allow:              f.Allow,
+			MinConfidence:      f.MinConfidence,
+			BlockUncategorized: f.BlockUncategorized,
+
+			HeuristicMinConfidence: f.HeuristicMinConfidence,
+		}, nil
+	}
+
+	var policy websitecategorization.Policy
+
+	data, err := os.ReadFile(f.File)
+	if err != nil {
+		return policy, err
+	}
+
+	if err = json.Unmarshal(data, &policy); err != nil {
+		return policy, fmt.Errorf("cannot parse policy: %w", err)
+	}
+
+	return policy, nil
+}
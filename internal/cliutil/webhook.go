@@ -0,0 +1,120 @@
+package cliutil
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	websitecategorization "github.com/whois-api-llc/website-categorization-go"
+)
+
+// notifierQueueSize is the maximum number of events waiting for the delivery.
+const notifierQueueSize = 1000
+
+// budgetThresholds are the fractions of the budget limits reported to the webhooks.
+var budgetThresholds = []float64{0.8, 1}
+
+// WebhookFlags holds the flags of the webhooks receiving the events of the command.
+type WebhookFlags struct {
+	URLs       string
+	Secret     string
+	Events     string
+	DeadLetter string
+}
+
+// Register registers the webhook flags in the flag set.
+func (f *WebhookFlags) Register(fs *flag.FlagSet) {
+	fs.StringVar(&f.URLs, "webhook-url", "", "comma-separated URLs the events are posted to: blocked websites "+
+		"and 80% and 100% of the budget limits")
+	fs.StringVar(&f.Secret, "webhook-secret", "", "key of the HMAC-SHA256 signature of the webhook deliveries")
+	fs.StringVar(&f.Events, "webhook-events", "", "comma-separated event types sent to the webhooks, all types if empty")
+	fs.StringVar(&f.DeadLetter, "webhook-dead-letter", "", "JSON Lines file the undelivered events are appended to")
+}
+
+// NewNotifier creates Notifier posting the events to the webhooks until the context is canceled.
+// It returns nil if no webhook URL is specified.
+func (f *WebhookFlags) NewNotifier(ctx context.Context, logger *log.Logger) (*Notifier, error) {
+	if f.URLs == "" {
+		return nil, nil
+	}
+
+	var events []websitecategorization.EventType
+
+	for _, field := range strings.Split(f.Events, ",") {
+		switch event := websitecategorization.EventType(strings.TrimSpace(field)); event {
+		case "":
+		case websitecategorization.EventCategoryChange, websitecategorization.EventPolicyViolation,
+			websitecategorization.EventBudgetThreshold:
+			events = append(events, event)
+		default:
+			return nil, fmt.Errorf("unknown webhook event type %q", event)
+		}
+	}
+
+	notifier := &websitecategorization.WebhookNotifier{DeadLetterPath: f.DeadLetter}
+
+	for _, field := range strings.Split(f.URLs, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			notifier.Webhooks = append(notifier.Webhooks,
+				websitecategorization.Webhook{URL: field, Secret: f.Secret, Events: events})
+		}
+	}
+
+	n := &Notifier{
+		notifier: notifier,
+		logger:   logger,
+		queue:    make(chan websitecategorization.WebhookEvent, notifierQueueSize),
+		done:     make(chan struct{}),
+	}
+
+	go n.run(ctx)
+
+	return n, nil
+}
+
+// Notifier posts the events to the webhooks in the background, so slow receivers do not delay the requests.
+// Delivery errors are logged. The methods of nil Notifier do nothing.
+type Notifier struct {
+	notifier *websitecategorization.WebhookNotifier
+	logger   *log.Logger
+
+	queue chan websitecategorization.WebhookEvent
+	done  chan struct{}
+}
+
+// Notify queues the event for the delivery. The event is dropped if the queue is full.
+// Notify must not be called after Close.
+func (n *Notifier) Notify(event websitecategorization.WebhookEvent) {
+	if n == nil {
+		return
+	}
+
+	select {
+	case n.queue <- event:
+	default:
+		n.logger.Printf("webhook queue is full, %s event %s is dropped", event.Type, event.ID)
+	}
+}
+
+// Close waits until the queued events are delivered.
+func (n *Notifier) Close() {
+	if n == nil {
+		return
+	}
+
+	close(n.queue)
+	<-n.done
+}
+
+// run delivers the queued events.
+func (n *Notifier) run(ctx context.Context) {
+	defer close(n.done)
+
+	for event := range n.queue {
+		if err := n.notifier.Notify(ctx, event); err != nil {
+			n.logger.Printf("cannot deliver %s event %s: %v", event.Type, event.ID, err)
+		}
+	}
+}
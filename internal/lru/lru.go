@@ -0,0 +1,101 @@
+// Package lru contains the in-memory LRU cache with the limited number of entries and their lifetime
+// shared by the library cache and the command line tools.
+package lru
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Cache is the in-memory cache evicting the least recently used entries first. It is safe for concurrent use.
+type Cache struct {
+	// Now returns the current time the entry lifetime is measured with. Default: time.Now.
+	Now func() time.Time
+
+	mu sync.Mutex
+
+	ttl        time.Duration
+	maxEntries int
+
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+// entry is the element of the LRU list.
+type entry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+// New creates Cache. Zero ttl means that entries never expire,
+// zero maxEntries means that the number of entries is not limited.
+func New(ttl time.Duration, maxEntries int) *Cache {
+	return &Cache{
+		Now:        time.Now,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		lru:        list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// Get returns the value stored under the key if it has not expired yet.
+func (c *Cache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	e := elem.Value.(*entry)
+	if !e.expires.IsZero() && !c.Now().Before(e.expires) {
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+
+		return nil, false
+	}
+
+	c.lru.MoveToFront(elem)
+
+	return e.value, true
+}
+
+// Set stores the value under the key evicting the least recently used entries above the limit.
+func (c *Cache) Set(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expires time.Time
+	if c.ttl > 0 {
+		expires = c.Now().Add(c.ttl)
+	}
+
+	if elem, ok := c.entries[key]; ok {
+		e := elem.Value.(*entry)
+		e.value = value
+		e.expires = expires
+		c.lru.MoveToFront(elem)
+
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&entry{key: key, value: value, expires: expires})
+
+	for c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry).key)
+	}
+}
+
+// Len returns the number of stored entries including expired ones.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Len()
+}
@@ -0,0 +1,45 @@
+package lru
+
+import (
+	"testing"
+	"time"
+)
+
+// TestCache tests expiration and eviction of the entries.
+func TestCache(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cache := New(time.Minute, 2)
+	cache.Now = func() time.Time { return now }
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+
+	if _, ok := cache.Get("a"); !ok {
+		t.Fatal("Get(a) is missing")
+	}
+
+	// "b" is the least recently used entry now.
+	cache.Set("c", 3)
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("Get(b) must be evicted")
+	}
+
+	// Replacing the value renews the lifetime.
+	now = now.Add(30 * time.Second)
+	cache.Set("c", 4)
+	now = now.Add(30 * time.Second)
+
+	if _, ok := cache.Get("a"); ok {
+		t.Error("Get(a) must be expired")
+	}
+
+	if got, ok := cache.Get("c"); !ok || got != 4 {
+		t.Errorf("Get(c) = %v, %v, want 4", got, ok)
+	}
+
+	if cache.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", cache.Len())
+	}
+}
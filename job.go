@@ -0,0 +1,575 @@
+package websitecategorization
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Job categorizes every domain of the input file and writes the results to the output file in the
+// JSON Lines format. The progress is saved to the checkpoint file, so an interrupted job started again
+// with the same parameters continues from the last saved position without requesting completed domains.
+//
+// The input file contains one domain name per line. Empty lines and lines starting with # are skipped.
+// The results are written in the order of the input file. Domains that could not be categorized
+// are written to the retry file, one per line, so it can be used as the input of another job.
+type Job struct {
+	// Service is used to categorize domains, usually it is *Client.
+	Service WCategorizationService
+
+	// InputPath is the path to the file with domain names.
+	InputPath string
+
+	// OutputPath is the path to the JSON Lines file with categorization results.
+	OutputPath string
+
+	// CheckpointPath is the path to the checkpoint file. Default: OutputPath + ".checkpoint".
+	CheckpointPath string
+
+	// CheckpointInterval is the period of checkpoint saves. The checkpoint is also saved when the job stops,
+	// a job killed between the saves repeats the requests made after the last save. Default: 5 seconds.
+	CheckpointInterval time.Duration
+
+	// RetryPath is the path to the file with failed domains. Default: OutputPath + ".retry".
+	RetryPath string
+
+	// Options are passed to every Get request.
+	Options []Option
+
+	// Concurrency is the number of simultaneous requests. Default: 1.
+	Concurrency int
+
+	// Progress is called periodically and when the job stops.
+	Progress func(JobProgress)
+
+	// ProgressInterval is the period of Progress calls. Default: 10 seconds.
+	ProgressInterval time.Duration
+}
+
+// JobProgress describes the state of the running Job.
+type JobProgress struct {
+	// Total is the number of lines in the input file.
+	Total int
+
+	// Processed is the number of processed input lines including the ones processed by previous runs.
+	Processed int
+
+	// Succeeded is the number of categorized domains.
+	Succeeded int
+
+	// Failed is the number of domains written to the retry file.
+	Failed int
+
+	// Elapsed is the duration of the current run.
+	Elapsed time.Duration
+
+	// ETA is the estimated remaining duration. It is zero until the first line is processed by the current run.
+	ETA time.Duration
+}
+
+// jobCheckpoint is the persisted position of the Job.
+type jobCheckpoint struct {
+	Input        string `json:"input"`
+	Line         int    `json:"line"`
+	OutputOffset int64  `json:"outputOffset"`
+	RetryOffset  int64  `json:"retryOffset"`
+	Succeeded    int    `json:"succeeded"`
+	Failed       int    `json:"failed"`
+}
+
+// jobTask is the input line to be processed.
+type jobTask struct {
+	line   int
+	domain string
+}
+
+// jobResult is the outcome of processing the input line.
+type jobResult struct {
+	jobTask
+
+	resp *WCategorizationResponse
+	err  error
+}
+
+// Run runs the job until all domains are processed, the context is canceled or the client budget is spent.
+// It returns the context error or BudgetExceededError in these cases, the job can be resumed by calling Run again.
+func (j *Job) Run(ctx context.Context) (JobProgress, error) {
+	var progress JobProgress
+
+	if j.Service == nil {
+		return progress, &ArgError{"Service", "can not be nil"}
+	}
+
+	if j.InputPath == "" || j.OutputPath == "" {
+		return progress, &ArgError{"InputPath, OutputPath", "can not be empty"}
+	}
+
+	total, err := countLines(j.InputPath)
+	if err != nil {
+		return progress, err
+	}
+
+	progress.Total = total
+
+	checkpoint, err := j.loadCheckpoint()
+	if err != nil {
+		return progress, err
+	}
+
+	progress.Processed = checkpoint.Line
+	progress.Succeeded = checkpoint.Succeeded
+	progress.Failed = checkpoint.Failed
+
+	output, err := openAt(j.OutputPath, checkpoint.OutputOffset)
+	if err != nil {
+		return progress, err
+	}
+	defer output.Close()
+
+	retry, err := openAt(j.retryPath(), checkpoint.RetryOffset)
+	if err != nil {
+		return progress, err
+	}
+	defer retry.Close()
+
+	input, err := os.Open(j.InputPath)
+	if err != nil {
+		return progress, err
+	}
+	defer input.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	concurrency := j.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	// window limits the number of results waiting to be written in order.
+	window := make(chan struct{}, concurrency*4)
+	tasks := make(chan jobTask)
+	results := make(chan jobResult)
+
+	readErrs := make(chan error, 1)
+
+	go func() {
+		defer close(tasks)
+
+		readErrs <- readTasks(ctx, input, checkpoint.Line, window, tasks)
+	}()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for task := range tasks {
+				results <- j.process(ctx, task)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	progress, err = j.write(ctx, cancel, results, window, checkpoint, output, retry, progress)
+	if readErr := <-readErrs; err == nil && readErr != nil {
+		err = readErr
+	}
+
+	return progress, err
+}
+
+// process categorizes the domain of the task.
+func (j *Job) process(ctx context.Context, task jobTask) jobResult {
+	result := jobResult{jobTask: task}
+
+	if task.domain == "" {
+		return result
+	}
+
+	result.resp, _, result.err = j.Service.Get(ctx, task.domain, j.Options...)
+
+	return result
+}
+
+// write writes the results in the input order and saves the checkpoint periodically and when the job stops.
+// It cancels the context on write errors, so the remaining tasks are not processed.
+func (j *Job) write(
+	ctx context.Context,
+	cancel context.CancelFunc,
+	results <-chan jobResult,
+	window <-chan struct{},
+	checkpoint jobCheckpoint,
+	output, retry *os.File,
+	progress JobProgress,
+) (JobProgress, error) {
+	started := time.Now()
+	startLine := checkpoint.Line
+
+	interval := j.ProgressInterval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	checkpointInterval := j.CheckpointInterval
+	if checkpointInterval <= 0 {
+		checkpointInterval = 5 * time.Second
+	}
+
+	outputWriter := NewJSONLWriter(output)
+	retryWriter := bufio.NewWriter(retry)
+
+	lastReport, lastSave := started, started
+
+	// unsaved is set when results are written after the last checkpoint, broken is set when the files
+	// may contain partially written data, so the checkpoint must not be saved anymore.
+	unsaved, broken := false, false
+
+	save := func() error {
+		lastSave = time.Now()
+		unsaved = false
+
+		err := j.saveProgress(&checkpoint, outputWriter, retryWriter, output, retry)
+		if err != nil {
+			broken = true
+		}
+
+		return err
+	}
+
+	report := func() {
+		progress.Processed = checkpoint.Line
+		progress.Succeeded = checkpoint.Succeeded
+		progress.Failed = checkpoint.Failed
+		progress.Elapsed = time.Since(started)
+
+		if done := checkpoint.Line - startLine; done > 0 {
+			progress.ETA = progress.Elapsed * time.Duration(progress.Total-checkpoint.Line) / time.Duration(done)
+		}
+
+		if j.Progress != nil {
+			j.Progress(progress)
+		}
+	}
+
+	pending := make(map[int]jobResult)
+
+	var stopErr error
+
+	for result := range results {
+		if stopErr != nil {
+			continue
+		}
+
+		pending[result.line] = result
+
+		for {
+			next, ok := pending[checkpoint.Line]
+			if !ok {
+				break
+			}
+
+			if next.err != nil && ctx.Err() != nil {
+				// The request was interrupted, the domain is processed again when the job is resumed.
+				stopErr = ctx.Err()
+
+				break
+			}
+
+			if errors.Is(next.err, ErrBudgetExceeded) {
+				// The job can be resumed when the budget allows.
+				stopErr = next.err
+
+				break
+			}
+
+			delete(pending, checkpoint.Line)
+			<-window
+
+			if err := j.writeResult(next, outputWriter, retryWriter); err != nil {
+				stopErr = err
+				broken = true
+
+				break
+			}
+
+			if next.domain != "" {
+				if next.err != nil {
+					checkpoint.Failed++
+				} else {
+					checkpoint.Succeeded++
+				}
+			}
+
+			checkpoint.Line++
+			unsaved = true
+		}
+
+		if unsaved && !broken && time.Since(lastSave) >= checkpointInterval {
+			if err := save(); err != nil {
+				stopErr = err
+			}
+		}
+
+		if stopErr != nil {
+			cancel()
+
+			continue
+		}
+
+		if time.Since(lastReport) >= interval {
+			lastReport = time.Now()
+
+			report()
+		}
+	}
+
+	if unsaved && !broken {
+		if err := save(); err != nil && stopErr == nil {
+			stopErr = err
+		}
+	}
+
+	if stopErr == nil {
+		stopErr = ctx.Err()
+	}
+
+	report()
+
+	return progress, stopErr
+}
+
+// writeResult writes the result to the output or the retry file.
+func (j *Job) writeResult(result jobResult, output *JSONLWriter, retry *bufio.Writer) error {
+	switch {
+	case result.domain == "":
+		return nil
+	case result.err != nil:
+		_, err := retry.WriteString(result.domain + "\n")
+
+		return err
+	}
+
+	return output.Write(result.resp)
+}
+
+// saveProgress flushes written results to the disk and saves the checkpoint with the current file offsets.
+func (j *Job) saveProgress(
+	checkpoint *jobCheckpoint,
+	outputWriter *JSONLWriter,
+	retryWriter *bufio.Writer,
+	output, retry *os.File,
+) error {
+	if err := outputWriter.Flush(); err != nil {
+		return err
+	}
+
+	if err := retryWriter.Flush(); err != nil {
+		return err
+	}
+
+	// The results must reach the disk before the checkpoint pointing past them.
+	if err := output.Sync(); err != nil {
+		return err
+	}
+
+	if err := retry.Sync(); err != nil {
+		return err
+	}
+
+	var err error
+
+	if checkpoint.OutputOffset, err = output.Seek(0, io.SeekCurrent); err != nil {
+		return err
+	}
+
+	if checkpoint.RetryOffset, err = retry.Seek(0, io.SeekCurrent); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	return syncFileAtomic(j.checkpointPath(), data, 0o600)
+}
+
+// loadCheckpoint loads the checkpoint of the previous run. A new checkpoint is returned if there is none.
+func (j *Job) loadCheckpoint() (jobCheckpoint, error) {
+	checkpoint := jobCheckpoint{Input: j.InputPath}
+
+	data, err := os.ReadFile(j.checkpointPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return checkpoint, nil
+	}
+
+	if err != nil {
+		return checkpoint, err
+	}
+
+	if err = json.Unmarshal(data, &checkpoint); err != nil {
+		return checkpoint, fmt.Errorf("cannot parse checkpoint: %w", err)
+	}
+
+	if checkpoint.Input != j.InputPath {
+		return checkpoint, fmt.Errorf("checkpoint %s belongs to the input %s", j.checkpointPath(), checkpoint.Input)
+	}
+
+	return checkpoint, nil
+}
+
+// checkpointPath returns the path to the checkpoint file.
+func (j *Job) checkpointPath() string {
+	if j.CheckpointPath != "" {
+		return j.CheckpointPath
+	}
+
+	return j.OutputPath + ".checkpoint"
+}
+
+// retryPath returns the path to the retry file.
+func (j *Job) retryPath() string {
+	if j.RetryPath != "" {
+		return j.RetryPath
+	}
+
+	return j.OutputPath + ".retry"
+}
+
+// readTasks sends the input lines starting with the specified one to the tasks channel.
+// Every task occupies a slot in the window until its result is written.
+func readTasks(ctx context.Context, input io.Reader, start int, window chan<- struct{}, tasks chan<- jobTask) error {
+	scanner := bufio.NewScanner(input)
+
+	for line := 0; scanner.Scan(); line++ {
+		if line < start {
+			continue
+		}
+
+		domain := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(domain, "#") {
+			domain = ""
+		}
+
+		select {
+		case window <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		select {
+		case tasks <- jobTask{line: line, domain: domain}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return scanner.Err()
+}
+
+// countLines returns the number of lines in the file.
+func countLines(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var lines int
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines++
+	}
+
+	return lines, scanner.Err()
+}
+
+// openAt opens the file for writing, truncates it to the offset and positions it at the end.
+func openAt(path string, offset int64) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = file.Truncate(offset); err != nil {
+		_ = file.Close()
+
+		return nil, err
+	}
+
+	if _, err = file.Seek(offset, io.SeekStart); err != nil {
+		_ = file.Close()
+
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// writeFileAtomic replaces the file content with the data, so the file is never left partially written.
+// The file gets the permissions perm.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	return replaceFile(path, data, perm, false)
+}
+
+// syncFileAtomic is writeFileAtomic that also syncs the data to the disk before the rename,
+// so the file is intact after a system crash too. It is slower, so it is used for the job checkpoints only.
+func syncFileAtomic(path string, data []byte, perm os.FileMode) error {
+	return replaceFile(path, data, perm, true)
+}
+
+// replaceFile writes the data to a temporary file and renames it to the path.
+func replaceFile(path string, data []byte, perm os.FileMode, sync bool) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	if err = tmp.Chmod(perm); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+
+		return err
+	}
+
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+
+		return err
+	}
+
+	if sync {
+		if err = tmp.Sync(); err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+
+			return err
+		}
+	}
+
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
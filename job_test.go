@@ -0,0 +1,210 @@
+package websitecategorization
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stubService is WCategorizationService with the Get function replaced by a callback.
+type stubService struct {
+	WCategorizationService
+
+	get func(ctx context.Context, domainName string) (*WCategorizationResponse, error)
+}
+
+// Get calls the callback.
+func (s *stubService) Get(ctx context.Context, domainName string, _ ...Option) (
+	*WCategorizationResponse, *Response, error) {
+	resp, err := s.get(ctx, domainName)
+
+	return resp, nil, err
+}
+
+// TestJobResume tests that the interrupted Job continues from the checkpoint.
+func TestJobResume(t *testing.T) {
+	dir := t.TempDir()
+
+	input := filepath.Join(dir, "domains.txt")
+	output := filepath.Join(dir, "results.jsonl")
+
+	err := os.WriteFile(input, []byte("a.com\n\n# comment\nb.com\nfail.com\nc.com\nd.com\ne.com\nf.com\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mu          sync.Mutex
+		requested   []string
+		interrupted bool
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	service := &stubService{get: func(ctx context.Context, domainName string) (*WCategorizationResponse, error) {
+		mu.Lock()
+		requested = append(requested, domainName)
+		mu.Unlock()
+
+		switch domainName {
+		case "fail.com":
+			return nil, errors.New("API failed")
+		case "d.com":
+			if !interrupted {
+				interrupted = true
+
+				cancel()
+
+				return nil, ctx.Err()
+			}
+		}
+
+		return &WCategorizationResponse{DomainName: domainName}, nil
+	}}
+
+	job := &Job{
+		Service:    service,
+		InputPath:  input,
+		OutputPath: output,
+	}
+
+	progress, err := job.Run(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Job.Run() error = %v, want %v", err, context.Canceled)
+	}
+
+	if progress.Processed != 6 || progress.Succeeded != 3 || progress.Failed != 1 || progress.Total != 9 {
+		t.Errorf("Job.Run() progress = %+v", progress)
+	}
+
+	requested = nil
+
+	var reports []JobProgress
+
+	job.Progress = func(p JobProgress) { reports = append(reports, p) }
+	job.Concurrency = 3
+
+	progress, err = job.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if progress.Processed != 9 || progress.Succeeded != 6 || progress.Failed != 1 {
+		t.Errorf("Job.Run() progress = %+v", progress)
+	}
+
+	if len(reports) == 0 || reports[len(reports)-1] != progress {
+		t.Errorf("Job.Progress reports = %+v", reports)
+	}
+
+	if got := strings.Join(sorted(requested), ","); got != "d.com,e.com,f.com" {
+		t.Errorf("resumed Job requested %s", got)
+	}
+
+	file, err := os.Open(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var domains []string
+
+	for r := NewJSONLReader(file); ; {
+		resp, err := r.Read()
+		if err != nil {
+			break
+		}
+
+		domains = append(domains, resp.DomainName)
+	}
+
+	if got := strings.Join(domains, ","); got != "a.com,b.com,c.com,d.com,e.com,f.com" {
+		t.Errorf("Job output domains = %s", got)
+	}
+
+	retry, err := os.ReadFile(output + ".retry")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(retry) != "fail.com\n" {
+		t.Errorf("Job retry file = %q", retry)
+	}
+
+	requested = nil
+
+	if _, err = job.Run(context.Background()); err != nil || len(requested) != 0 {
+		t.Errorf("completed Job.Run() error = %v, requested %v", err, requested)
+	}
+}
+
+// TestJobCheckpointInterval tests that the checkpoint is saved when the job stops, not after every result.
+func TestJobCheckpointInterval(t *testing.T) {
+	dir := t.TempDir()
+
+	input := filepath.Join(dir, "domains.txt")
+	output := filepath.Join(dir, "results.jsonl")
+
+	if err := os.WriteFile(input, []byte("a.com\nb.com\nc.com\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var saved []string
+
+	service := &stubService{get: func(ctx context.Context, domainName string) (*WCategorizationResponse, error) {
+		if _, err := os.Stat(output + ".checkpoint"); err == nil {
+			saved = append(saved, domainName)
+		}
+
+		return &WCategorizationResponse{DomainName: domainName}, nil
+	}}
+
+	job := &Job{
+		Service:            service,
+		InputPath:          input,
+		OutputPath:         output,
+		CheckpointInterval: time.Hour,
+	}
+
+	if _, err := job.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(saved) != 0 {
+		t.Errorf("checkpoint is saved before requesting %v", saved)
+	}
+
+	checkpoint, err := job.loadCheckpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if checkpoint.Line != 3 || checkpoint.Succeeded != 3 || checkpoint.OutputOffset != info.Size() {
+		t.Errorf("checkpoint = %+v, output size %d", checkpoint, info.Size())
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	if len(matches) != 0 {
+		t.Errorf("temporary files are left: %v", matches)
+	}
+}
+
+// sorted returns the sorted copy of the slice.
+func sorted(values []string) []string {
+	values = append([]string(nil), values...)
+	sort.Strings(values)
+
+	return values
+}
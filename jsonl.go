@@ -0,0 +1,79 @@
+package websitecategorization
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// JSONLWriter writes WCategorizationResponse values in the JSON Lines format, one response per line.
+// The fields are encoded the same way as in the API response.
+type JSONLWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+// NewJSONLWriter creates JSONLWriter.
+func NewJSONLWriter(w io.Writer) *JSONLWriter {
+	bw := bufio.NewWriter(w)
+
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+
+	return &JSONLWriter{
+		w:   bw,
+		enc: enc,
+	}
+}
+
+// Write writes the response as a single line.
+func (w *JSONLWriter) Write(resp *WCategorizationResponse) error {
+	return w.enc.Encode(resp)
+}
+
+// Flush writes buffered lines to the underlying writer.
+func (w *JSONLWriter) Flush() error {
+	return w.w.Flush()
+}
+
+// JSONLReader reads WCategorizationResponse values in the JSON Lines format. Empty lines are skipped.
+type JSONLReader struct {
+	s    *bufio.Scanner
+	line int
+}
+
+// maxJSONLLineLen is the maximum length of a JSON line.
+const maxJSONLLineLen = 1 << 20
+
+// NewJSONLReader creates JSONLReader.
+func NewJSONLReader(r io.Reader) *JSONLReader {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), maxJSONLLineLen)
+
+	return &JSONLReader{s: s}
+}
+
+// Read returns the next response. It returns io.EOF when there are no more responses.
+func (r *JSONLReader) Read() (*WCategorizationResponse, error) {
+	for r.s.Scan() {
+		r.line++
+
+		if len(r.s.Bytes()) == 0 {
+			continue
+		}
+
+		var resp WCategorizationResponse
+		if err := json.Unmarshal(r.s.Bytes(), &resp); err != nil {
+			return nil, fmt.Errorf("line %d: %w", r.line, err)
+		}
+
+		return &resp, nil
+	}
+
+	if err := r.s.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, io.EOF
+}
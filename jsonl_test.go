@@ -0,0 +1,63 @@
+package websitecategorization
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestJSONLRoundTrip tests that JSONLReader restores the responses written by JSONLWriter.
+func TestJSONLRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := NewJSONLWriter(&buf)
+	for _, resp := range testResponses() {
+		if err := w.Write(resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	const wantSecondLine = `{"domainName":"unknown.example","categories":null,"websiteResponded":false}`
+
+	if lines := strings.Split(buf.String(), "\n"); len(lines) != 3 || lines[1] != wantSecondLine {
+		t.Fatalf("JSONLWriter output = %s", buf.String())
+	}
+
+	r := NewJSONLReader(strings.NewReader(buf.String() + "\n"))
+
+	var got []*WCategorizationResponse
+
+	for {
+		resp, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got = append(got, resp)
+	}
+
+	if want := testResponses(); !reflect.DeepEqual(got, want) {
+		t.Errorf("JSONLReader.Read() = %+v, want %+v", got, want)
+	}
+
+	if _, err := NewJSONLReader(strings.NewReader("{}\n{")).Read(); err != nil {
+		t.Errorf("JSONLReader.Read() error = %v", err)
+	}
+
+	r = NewJSONLReader(strings.NewReader("{}\n{"))
+	_, _ = r.Read()
+
+	if _, err := r.Read(); err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+		t.Errorf("JSONLReader.Read() error = %v, want error on line 2", err)
+	}
+}
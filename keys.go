@@ -0,0 +1,285 @@
+package websitecategorization
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrAPIKeysExhausted is returned when none of the API keys can be used.
+var ErrAPIKeysExhausted = errors.New("all API keys are exhausted")
+
+// APIKey is the API key with its rotation parameters.
+type APIKey struct {
+	// Key is the API key itself. It is never included in errors or key statuses.
+	Key string
+
+	// Name is the label of the key, e.g. the cost center. Default: the key fingerprint.
+	Name string
+
+	// Weight is the share of requests made with the key in the KeyRotationWeighted mode. Default: 1.
+	Weight int
+}
+
+// KeyRotation is the strategy of choosing the API key for a request.
+type KeyRotation int
+
+const (
+	// KeyRotationFailover uses the first available key in the specified order.
+	KeyRotationFailover KeyRotation = iota
+
+	// KeyRotationRoundRobin uses available keys in turn.
+	KeyRotationRoundRobin
+
+	// KeyRotationWeighted uses available keys in proportion to their weights.
+	KeyRotationWeighted
+)
+
+const (
+	// defaultKeyExhaustedPause is the time a rejected key is not used for.
+	defaultKeyExhaustedPause = time.Hour
+
+	// defaultKeyRateLimitedPause is the time a rate-limited key is not used for.
+	defaultKeyRateLimitedPause = time.Minute
+)
+
+// KeyStatus is the health of the API key.
+type KeyStatus struct {
+	// Name is the key name or fingerprint.
+	Name string
+
+	// Requests is the number of requests made with the key.
+	Requests int
+
+	// Failures is the number of requests rejected because of the key.
+	Failures int
+
+	// DisabledUntil is the time the key is not used until, zero if the key is available.
+	DisabledUntil time.Time
+}
+
+// keyState is the API key with its health.
+type keyState struct {
+	APIKey
+
+	requests      int
+	failures      int
+	disabledUntil time.Time
+
+	// currentWeight is used by the smooth weighted round-robin.
+	currentWeight int
+}
+
+// keyPool chooses API keys for requests and tracks their health. It is safe for concurrent use.
+type keyPool struct {
+	mu sync.Mutex
+
+	keys     []*keyState
+	rotation KeyRotation
+	next     int
+
+	exhaustedPause   time.Duration
+	rateLimitedPause time.Duration
+
+	now func() time.Time
+}
+
+// newKeyPool creates keyPool with the specified keys. Keys with empty values are ignored
+// unless all of them are empty.
+func newKeyPool(keys []APIKey, rotation KeyRotation) *keyPool {
+	pool := &keyPool{
+		rotation:         rotation,
+		exhaustedPause:   defaultKeyExhaustedPause,
+		rateLimitedPause: defaultKeyRateLimitedPause,
+		now:              time.Now,
+	}
+
+	for _, key := range keys {
+		if key.Key == "" && len(keys) > 1 {
+			continue
+		}
+
+		if key.Name == "" {
+			key.Name = keyFingerprint(key.Key)
+		}
+
+		if key.Weight <= 0 {
+			key.Weight = 1
+		}
+
+		pool.keys = append(pool.keys, &keyState{APIKey: key})
+	}
+
+	return pool
+}
+
+// acquire returns the index of the key to use for the next request. The keys in the exclude set are skipped.
+// The only key of the pool is always available, so single-key clients are not affected by key health.
+func (p *keyPool) acquire(exclude map[int]bool) (int, string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.keys) == 1 && !exclude[0] {
+		p.keys[0].requests++
+
+		return 0, p.keys[0].Key, nil
+	}
+
+	now := p.now()
+
+	available := func(i int) bool {
+		return !exclude[i] && !now.Before(p.keys[i].disabledUntil)
+	}
+
+	chosen := -1
+
+	switch p.rotation {
+	case KeyRotationRoundRobin:
+		for n := 0; n < len(p.keys); n++ {
+			i := (p.next + n) % len(p.keys)
+			if available(i) {
+				chosen = i
+				p.next = i + 1
+
+				break
+			}
+		}
+	case KeyRotationWeighted:
+		total := 0
+
+		for i, key := range p.keys {
+			if !available(i) {
+				continue
+			}
+
+			key.currentWeight += key.Weight
+			total += key.Weight
+
+			if chosen < 0 || key.currentWeight > p.keys[chosen].currentWeight {
+				chosen = i
+			}
+		}
+
+		if chosen >= 0 {
+			p.keys[chosen].currentWeight -= total
+		}
+	default:
+		for i := range p.keys {
+			if available(i) {
+				chosen = i
+
+				break
+			}
+		}
+	}
+
+	if chosen < 0 {
+		return 0, "", ErrAPIKeysExhausted
+	}
+
+	p.keys[chosen].requests++
+
+	return chosen, p.keys[chosen].Key, nil
+}
+
+// report updates the key health according to the response status code.
+// It returns true if the request should be repeated with another key.
+func (p *keyPool) report(i int, resp *http.Response) bool {
+	if resp == nil {
+		return false
+	}
+
+	var pause time.Duration
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusPaymentRequired, http.StatusForbidden:
+		pause = p.exhaustedPause
+	case http.StatusTooManyRequests:
+		pause = p.rateLimitedPause
+	default:
+		return false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := p.keys[i]
+	key.failures++
+
+	if len(p.keys) == 1 {
+		return false
+	}
+
+	key.disabledUntil = p.now().Add(pause)
+
+	return true
+}
+
+// statuses returns the health of all keys.
+func (p *keyPool) statuses() []KeyStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	statuses := make([]KeyStatus, len(p.keys))
+
+	for i, key := range p.keys {
+		statuses[i] = KeyStatus{
+			Name:     key.Name,
+			Requests: key.requests,
+			Failures: key.failures,
+		}
+
+		if now.Before(key.disabledUntil) {
+			statuses[i].DisabledUntil = key.disabledUntil
+		}
+	}
+
+	return statuses
+}
+
+// redactedKey replaces API keys in URLs exposed in errors and responses.
+const redactedKey = "REDACTED"
+
+// redactURL returns the copy of the URL with the API key replaced.
+func redactURL(u *url.URL) *url.URL {
+	if u == nil || !strings.Contains(u.RawQuery, "apiKey=") {
+		return u
+	}
+
+	redacted := *u
+
+	query := redacted.Query()
+	query.Set("apiKey", redactedKey)
+	redacted.RawQuery = query.Encode()
+
+	return &redacted
+}
+
+// redactError replaces the API key in the URL of the request error.
+func redactError(err error) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+
+	if u, parseErr := url.Parse(urlErr.URL); parseErr == nil {
+		urlErr.URL = redactURL(u).String()
+	}
+
+	return err
+}
+
+// redactResponse replaces the API key in the request of the response.
+func redactResponse(resp *http.Response) {
+	if resp == nil || resp.Request == nil {
+		return
+	}
+
+	req := *resp.Request
+	req.URL = redactURL(req.URL)
+	resp.Request = &req
+}
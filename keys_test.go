@@ -0,0 +1,186 @@
+package websitecategorization
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestKeyPoolRotation tests the key rotation strategies.
+func TestKeyPoolRotation(t *testing.T) {
+	keys := []APIKey{{Key: "a", Weight: 3}, {Key: "b"}, {Key: "c", Weight: 0}}
+
+	tests := []struct {
+		name     string
+		rotation KeyRotation
+		want     string
+	}{
+		{
+			name:     "failover",
+			rotation: KeyRotationFailover,
+			want:     "aaaaaa",
+		},
+		{
+			name:     "round-robin",
+			rotation: KeyRotationRoundRobin,
+			want:     "abcabc",
+		},
+		{
+			name:     "weighted",
+			rotation: KeyRotationWeighted,
+			want:     "abacaabaca",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := newKeyPool(keys, tt.rotation)
+
+			var got string
+
+			for i := 0; i < len(tt.want); i++ {
+				_, key, err := pool.acquire(nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				got += key
+			}
+
+			if got != tt.want {
+				t.Errorf("keyPool.acquire() sequence = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestClientKeyFailover tests that requests rejected because of the key are repeated with another key.
+func TestClientKeyFailover(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Query().Get("apiKey") {
+		case "at_ok":
+			_, _ = w.Write([]byte(`{"domainName":"whoisxmlapi.com","categories":[]}`))
+		case "at_limited":
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"code":403,"messages":"Access restricted. Check credits balance."}`))
+		}
+	}))
+	defer server.Close()
+
+	apiURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newClient := func(keys ...APIKey) *Client {
+		return NewClient("at_empty", ClientParams{
+			HTTPClient:             server.Client(),
+			WCategorizationBaseURL: apiURL,
+			APIKeys:                keys,
+			KeyRotation:            KeyRotationRoundRobin,
+		})
+	}
+
+	ctx := context.Background()
+
+	client := newClient(APIKey{Key: "at_limited", Name: "marketing"}, APIKey{Key: "at_ok", Name: "research"})
+
+	for i := 0; i < 3; i++ {
+		if _, _, err = client.Get(ctx, "whoisxmlapi.com"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	statuses := client.KeyStatuses()
+	if len(statuses) != 3 {
+		t.Fatalf("Client.KeyStatuses() = %+v", statuses)
+	}
+
+	if statuses[0].DisabledUntil.IsZero() || statuses[1].DisabledUntil.IsZero() || !statuses[2].DisabledUntil.IsZero() {
+		t.Errorf("Client.KeyStatuses() = %+v, want first two keys disabled", statuses)
+	}
+
+	if statuses[1].Name != "marketing" || statuses[2].Requests != 3 || statuses[0].Name != keyFingerprint("at_empty") {
+		t.Errorf("Client.KeyStatuses() = %+v", statuses)
+	}
+
+	resp, err := client.GetRaw(ctx, "whoisxmlapi.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := resp.Request.URL.Query().Get("apiKey"); got != redactedKey {
+		t.Errorf("Response.Request apiKey = %s, want %s", got, redactedKey)
+	}
+
+	client.keys.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+	if _, err = client.GetRaw(ctx, "whoisxmlapi.com"); err != nil {
+		t.Errorf("Client.GetRaw() error = %v after the keys are enabled again", err)
+	}
+
+	if _, _, err = newClient(APIKey{Key: "at_denied"}).Get(ctx, "whoisxmlapi.com"); !errors.Is(err, ErrAPIKeysExhausted) {
+		t.Errorf("Client.Get() error = %v, want %v", err, ErrAPIKeysExhausted)
+	}
+
+	// The only key is used regardless of its health.
+	single := NewClient("at_denied", ClientParams{HTTPClient: server.Client(), WCategorizationBaseURL: apiURL})
+	for i := 0; i < 2; i++ {
+		if _, err = single.GetRaw(ctx, "whoisxmlapi.com"); err == nil || !strings.Contains(err.Error(), "403") {
+			t.Errorf("Client.GetRaw() error = %v, want status code 403", err)
+		}
+	}
+
+	server.Close()
+
+	if _, err = client.GetRaw(ctx, "whoisxmlapi.com"); err == nil || strings.Contains(err.Error(), "at_") {
+		t.Errorf("Client.GetRaw() error = %v, want error without the API key", err)
+	}
+}
+
+// TestClientKeyFailoverBudget tests that the requests rejected because of the key do not count against the budget.
+func TestClientKeyFailoverBudget(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Query().Get("apiKey") {
+		case "at_ok":
+			_, _ = w.Write([]byte(`{"domainName":"whoisxmlapi.com","categories":[]}`))
+		case "at_limited":
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	apiURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	budget, err := NewBudget(BudgetLimits{Total: 2}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewClient("at_denied", ClientParams{
+		HTTPClient:             server.Client(),
+		WCategorizationBaseURL: apiURL,
+		APIKeys:                []APIKey{{Key: "at_limited"}, {Key: "at_ok"}},
+		Budget:                 budget,
+	})
+
+	if _, _, err = client.Get(context.Background(), "whoisxmlapi.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	if usage := budget.Usage(); usage.Total != 1 || usage.Keys[keyFingerprint("at_denied")] != 0 ||
+		usage.Keys[keyFingerprint("at_ok")] != 1 {
+		t.Errorf("Budget.Usage() = %+v, want one request made with at_ok", usage)
+	}
+}
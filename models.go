@@ -29,6 +29,14 @@ type WCategorizationResponse struct {
 
 	// WebsiteResponded Determines if the website was active during the crawling.
 	WebsiteResponded bool `json:"websiteResponded"`
+
+	// MatchedDomain is the parent domain the categorization belongs to when the result for DomainName
+	// is taken from its parent, see WithSubdomainFallback. It is not a part of the API response.
+	MatchedDomain string `json:"matchedDomain,omitempty"`
+
+	// Source is the origin of the response when it is not returned by the API, e.g. SourceOverride.
+	// It is empty for API responses and is not a part of the API response.
+	Source ResultSource `json:"source,omitempty"`
 }
 
 // Category is a part of the Website Categorization API v3 response.
@@ -71,3 +79,29 @@ type ErrorMessage struct {
 func (e *ErrorMessage) Error() string {
 	return fmt.Sprintf("API error: [%d] %s", e.Code, e.Message)
 }
+
+// clone returns a deep copy of the response.
+func (r *WCategorizationResponse) clone() *WCategorizationResponse {
+	if r == nil {
+		return nil
+	}
+
+	c := *r
+
+	if r.AS != nil {
+		as := *r.AS
+		c.AS = &as
+	}
+
+	if r.Categories != nil {
+		c.Categories = make([]Category, len(r.Categories))
+		copy(c.Categories, r.Categories)
+	}
+
+	if r.CreatedDate != nil {
+		createdDate := *r.CreatedDate
+		c.CreatedDate = &createdDate
+	}
+
+	return &c
+}
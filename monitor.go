@@ -0,0 +1,356 @@
+package websitecategorization
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ChangeKind is the kind of difference between two categorization results of a domain.
+type ChangeKind string
+
+const (
+	// ChangeCategoryAdded means that the domain got a new category.
+	ChangeCategoryAdded ChangeKind = "categoryAdded"
+
+	// ChangeCategoryRemoved means that the domain lost a category.
+	ChangeCategoryRemoved ChangeKind = "categoryRemoved"
+
+	// ChangeConfidence means that the confidence of a category shifted beyond the threshold.
+	ChangeConfidence ChangeKind = "confidence"
+
+	// ChangeWebsiteResponded means that WebsiteResponded flipped.
+	ChangeWebsiteResponded ChangeKind = "websiteResponded"
+
+	// ChangeAS means that the Autonomous System changed.
+	ChangeAS ChangeKind = "as"
+)
+
+// Change is a difference between two categorization results of a domain.
+type Change struct {
+	// Kind is the kind of the change.
+	Kind ChangeKind `json:"kind"`
+
+	// Category is the added, removed or shifted category. It is nil for other kinds.
+	Category *Category `json:"category,omitempty"`
+
+	// PreviousConfidence is the previous confidence of the shifted category.
+	PreviousConfidence float64 `json:"previousConfidence,omitempty"`
+}
+
+// confidenceEpsilon is the tolerance of the confidence comparisons. Confidences are decimal fractions,
+// so their float differences are slightly off, e.g. 0.7-0.6 is 0.09999999999999998.
+const confidenceEpsilon = 1e-9
+
+// Diff returns the changes between the previous and the current categorization results of a domain.
+// Confidence shifts are reported when they are greater than or equal to the threshold, zero or negative
+// threshold reports any shift. Category changes are sorted by category ID and go before other changes.
+func Diff(previous, current *WCategorizationResponse, threshold float64) []Change {
+	if previous == nil || current == nil {
+		return nil
+	}
+
+	before := make(map[int]Category, len(previous.Categories))
+	for _, category := range previous.Categories {
+		before[category.ID] = category
+	}
+
+	after := make(map[int]Category, len(current.Categories))
+	for _, category := range current.Categories {
+		after[category.ID] = category
+	}
+
+	var changes []Change
+
+	for id, category := range after {
+		category := category
+
+		prev, ok := before[id]
+
+		switch {
+		case !ok:
+			changes = append(changes, Change{Kind: ChangeCategoryAdded, Category: &category})
+		case category.Confidence != prev.Confidence &&
+			math.Abs(category.Confidence-prev.Confidence) >= threshold-confidenceEpsilon:
+			changes = append(changes, Change{
+				Kind:               ChangeConfidence,
+				Category:           &category,
+				PreviousConfidence: prev.Confidence,
+			})
+		}
+	}
+
+	for id, category := range before {
+		category := category
+
+		if _, ok := after[id]; !ok {
+			changes = append(changes, Change{Kind: ChangeCategoryRemoved, Category: &category})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Category.ID < changes[j].Category.ID })
+
+	if previous.WebsiteResponded != current.WebsiteResponded {
+		changes = append(changes, Change{Kind: ChangeWebsiteResponded})
+	}
+
+	if !sameAS(previous.AS, current.AS) {
+		changes = append(changes, Change{Kind: ChangeAS})
+	}
+
+	return changes
+}
+
+// sameAS reports whether both Autonomous Systems are the same.
+func sameAS(a, b *AS) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
+
+// ChangeEvent is emitted by Monitor when the categorization result of a domain changes.
+type ChangeEvent struct {
+	// DomainName is the monitored domain name.
+	DomainName string `json:"domainName"`
+
+	// Time is the time of the check that found the changes.
+	Time time.Time `json:"time"`
+
+	// Previous is the stored result.
+	Previous *WCategorizationResponse `json:"previous"`
+
+	// Current is the new result.
+	Current *WCategorizationResponse `json:"current"`
+
+	// Changes are the differences, see Diff.
+	Changes []Change `json:"changes"`
+}
+
+// MonitorStore stores the last categorization results of the monitored domains.
+// Implementations must be safe for concurrent use.
+type MonitorStore interface {
+	// Load returns the last result of the domain or nil if there is none.
+	Load(domainName string) (*WCategorizationResponse, error)
+
+	// Save stores the result of the domain checked at the time.
+	Save(domainName string, resp *WCategorizationResponse, checked time.Time) error
+}
+
+// MemoryMonitorStore is the in-memory MonitorStore.
+type MemoryMonitorStore struct {
+	mu      sync.RWMutex
+	results map[string]*WCategorizationResponse
+}
+
+// NewMemoryMonitorStore creates MemoryMonitorStore.
+func NewMemoryMonitorStore() *MemoryMonitorStore {
+	return &MemoryMonitorStore{results: make(map[string]*WCategorizationResponse)}
+}
+
+// Load returns a copy of the last result of the domain.
+func (s *MemoryMonitorStore) Load(domainName string) (*WCategorizationResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.results[domainName].clone(), nil
+}
+
+// Save stores a copy of the result.
+func (s *MemoryMonitorStore) Save(domainName string, resp *WCategorizationResponse, _ time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.results[domainName] = resp.clone()
+
+	return nil
+}
+
+// Scheduler decides when Monitor checks the watchlist.
+type Scheduler interface {
+	// Next returns the time of the check following the one started at the time.
+	Next(last time.Time) time.Time
+}
+
+// Every is the Scheduler that checks the watchlist with the fixed period.
+type Every time.Duration
+
+// Next returns the time of the next check.
+func (e Every) Next(last time.Time) time.Time {
+	return last.Add(time.Duration(e))
+}
+
+// SchedulerFunc is an adapter to use a function as Scheduler.
+type SchedulerFunc func(last time.Time) time.Time
+
+// Next calls f(last).
+func (f SchedulerFunc) Next(last time.Time) time.Time {
+	return f(last)
+}
+
+// Monitor periodically categorizes the watchlist of domains and emits ChangeEvent for every domain whose result
+// differs from the stored one. The first result of a domain is stored without an event.
+//
+// The service should not serve results older than the schedule period, e.g. the cache lifetime of the Client
+// should be shorter than the period.
+type Monitor struct {
+	// Service is used to categorize domains, usually it is *Client.
+	Service WCategorizationService
+
+	// Domains is the watchlist. It must not be modified while the monitor runs.
+	Domains []string
+
+	// Options are passed to every Get request.
+	Options []Option
+
+	// Store keeps the previous results. Default: MemoryMonitorStore.
+	Store MonitorStore
+
+	// Schedule decides when the watchlist is checked. Default: Every(24 * time.Hour).
+	Schedule Scheduler
+
+	// ConfidenceThreshold is the minimum confidence shift reported as ChangeConfidence, see Diff.
+	// Default: 0.1. A negative threshold reports any shift.
+	ConfidenceThreshold float64
+
+	// Concurrency is the number of simultaneous requests. Default: 1.
+	Concurrency int
+
+	// OnChange is called for every changed domain. It can be called concurrently when Concurrency is above 1.
+	OnChange func(ChangeEvent)
+
+	// OnError is called for every domain that could not be checked. It can be called concurrently
+	// when Concurrency is above 1.
+	OnError func(domainName string, err error)
+
+	// now returns the current time, it is replaced in tests.
+	now func() time.Time
+
+	initOnce sync.Once
+}
+
+// init sets the defaults.
+func (m *Monitor) init() {
+	m.initOnce.Do(func() {
+		if m.Store == nil {
+			m.Store = NewMemoryMonitorStore()
+		}
+
+		if m.Schedule == nil {
+			m.Schedule = Every(24 * time.Hour)
+		}
+
+		if m.ConfidenceThreshold == 0 {
+			m.ConfidenceThreshold = 0.1
+		}
+
+		if m.Concurrency < 1 {
+			m.Concurrency = 1
+		}
+
+		if m.now == nil {
+			m.now = time.Now
+		}
+	})
+}
+
+// Run checks the watchlist according to the schedule until the context is done. It returns the context error.
+func (m *Monitor) Run(ctx context.Context) error {
+	m.init()
+
+	for ctx.Err() == nil {
+		started := m.now()
+
+		if err := m.Check(ctx); err != nil {
+			return err
+		}
+
+		timer := time.NewTimer(m.Schedule.Next(started).Sub(m.now()))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	return ctx.Err()
+}
+
+// Check checks every domain of the watchlist once. It returns ArgError if Service is nil
+// or the context error if the check is interrupted, errors of single domains are passed to OnError.
+func (m *Monitor) Check(ctx context.Context) error {
+	if m.Service == nil {
+		return &ArgError{"Service", "can not be nil"}
+	}
+
+	m.init()
+
+	domains := make(chan string)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < m.Concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for domainName := range domains {
+				if err := m.check(ctx, domainName); err != nil && ctx.Err() == nil && m.OnError != nil {
+					m.OnError(domainName, err)
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, domainName := range m.Domains {
+		select {
+		case domains <- domainName:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+
+	close(domains)
+	wg.Wait()
+
+	return ctx.Err()
+}
+
+// check categorizes the domain and emits the change event.
+func (m *Monitor) check(ctx context.Context, domainName string) error {
+	current, _, err := m.Service.Get(ctx, domainName, m.Options...)
+	if err != nil {
+		return err
+	}
+
+	checked := m.now()
+
+	previous, err := m.Store.Load(domainName)
+	if err != nil {
+		return err
+	}
+
+	if err = m.Store.Save(domainName, current, checked); err != nil {
+		return err
+	}
+
+	if changes := Diff(previous, current, m.ConfidenceThreshold); len(changes) > 0 && m.OnChange != nil {
+		m.OnChange(ChangeEvent{
+			DomainName: domainName,
+			Time:       checked,
+			Previous:   previous,
+			Current:    current,
+			Changes:    changes,
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,248 @@
+package websitecategorization
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestDiff tests the detection of changes between categorization results.
+func TestDiff(t *testing.T) {
+	previous := &WCategorizationResponse{
+		AS:               &AS{ASN: 1, Name: "AS1"},
+		Categories:       []Category{{ID: 1, Confidence: 0.9}, {ID: 2, Confidence: 0.5}, {ID: 3, Confidence: 0.7}},
+		WebsiteResponded: true,
+	}
+
+	tests := []struct {
+		name      string
+		current   *WCategorizationResponse
+		threshold float64
+		want      []ChangeKind
+	}{
+		{
+			name:    "unchanged",
+			current: previous.clone(),
+		},
+		{
+			name: "categories added and removed",
+			current: &WCategorizationResponse{
+				AS:               &AS{ASN: 1, Name: "AS1"},
+				Categories:       []Category{{ID: 4, Confidence: 0.8}, {ID: 1, Confidence: 0.9}, {ID: 2, Confidence: 0.5}},
+				WebsiteResponded: true,
+			},
+			want: []ChangeKind{ChangeCategoryRemoved, ChangeCategoryAdded},
+		},
+		{
+			name: "confidence shift below the threshold",
+			current: &WCategorizationResponse{
+				AS:               &AS{ASN: 1, Name: "AS1"},
+				Categories:       []Category{{ID: 1, Confidence: 0.85}, {ID: 2, Confidence: 0.5}, {ID: 3, Confidence: 0.7}},
+				WebsiteResponded: true,
+			},
+			threshold: 0.1,
+		},
+		{
+			name: "confidence shift beyond the threshold",
+			current: &WCategorizationResponse{
+				AS:               &AS{ASN: 1, Name: "AS1"},
+				Categories:       []Category{{ID: 1, Confidence: 0.6}, {ID: 2, Confidence: 0.5}, {ID: 3, Confidence: 0.7}},
+				WebsiteResponded: true,
+			},
+			threshold: 0.1,
+			want:      []ChangeKind{ChangeConfidence},
+		},
+		{
+			name: "confidence shift equal to the threshold",
+			current: &WCategorizationResponse{
+				AS:               &AS{ASN: 1, Name: "AS1"},
+				Categories:       []Category{{ID: 1, Confidence: 0.9}, {ID: 2, Confidence: 0.5}, {ID: 3, Confidence: 0.6}},
+				WebsiteResponded: true,
+			},
+			threshold: 0.1,
+			want:      []ChangeKind{ChangeConfidence},
+		},
+		{
+			name: "any confidence shift with zero threshold",
+			current: &WCategorizationResponse{
+				AS:               &AS{ASN: 1, Name: "AS1"},
+				Categories:       []Category{{ID: 1, Confidence: 0.89}, {ID: 2, Confidence: 0.5}, {ID: 3, Confidence: 0.7}},
+				WebsiteResponded: true,
+			},
+			want: []ChangeKind{ChangeConfidence},
+		},
+		{
+			name: "website responded and AS",
+			current: &WCategorizationResponse{
+				AS:         &AS{ASN: 2, Name: "AS2"},
+				Categories: previous.Categories,
+			},
+			want: []ChangeKind{ChangeWebsiteResponded, ChangeAS},
+		},
+		{
+			name: "AS removed",
+			current: &WCategorizationResponse{
+				Categories:       previous.Categories,
+				WebsiteResponded: true,
+			},
+			want: []ChangeKind{ChangeAS},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []ChangeKind
+			for _, change := range Diff(previous, tt.current, tt.threshold) {
+				got = append(got, change.Kind)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	shifted := Diff(previous, tests[3].current, 0.1)
+	if shifted[0].Category.ID != 1 || shifted[0].Category.Confidence != 0.6 || shifted[0].PreviousConfidence != 0.9 {
+		t.Errorf("Diff() confidence change = %+v", shifted[0])
+	}
+
+	if changes := Diff(nil, previous, 0); changes != nil {
+		t.Errorf("Diff() without the previous result = %v", changes)
+	}
+}
+
+// TestMonitor tests that Monitor emits events for changed domains according to the schedule.
+func TestMonitor(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		checks = make(map[string]int)
+	)
+
+	service := &stubService{get: func(ctx context.Context, domainName string) (*WCategorizationResponse, error) {
+		mu.Lock()
+		checks[domainName]++
+		n := checks[domainName]
+		mu.Unlock()
+
+		switch {
+		case domainName == "fail.com":
+			return nil, errors.New("API failed")
+		case domainName == "partner.com" && n > 1:
+			return &WCategorizationResponse{DomainName: domainName, Categories: []Category{{ID: 1, Confidence: 0.9}}}, nil
+		}
+
+		return &WCategorizationResponse{DomainName: domainName, Categories: []Category{{ID: 5, Confidence: 0.9}}}, nil
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		events []ChangeEvent
+		failed []string
+		runs   int
+	)
+
+	monitor := &Monitor{
+		Service:     service,
+		Domains:     []string{"partner.com", "stable.com", "fail.com"},
+		Concurrency: 2,
+		Schedule: SchedulerFunc(func(last time.Time) time.Time {
+			runs++
+			if runs == 3 {
+				cancel()
+			}
+
+			return last
+		}),
+		OnChange: func(event ChangeEvent) {
+			mu.Lock()
+			events = append(events, event)
+			mu.Unlock()
+		},
+		OnError: func(domainName string, err error) {
+			mu.Lock()
+			failed = append(failed, domainName)
+			mu.Unlock()
+		},
+	}
+
+	if err := monitor.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run() error = %v, want context.Canceled", err)
+	}
+
+	if checks["stable.com"] != 3 {
+		t.Errorf("stable.com is checked %d times, want 3", checks["stable.com"])
+	}
+
+	if len(failed) != 3 {
+		t.Errorf("OnError() is called for %v, want 3 calls", failed)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("events = %+v, want 1 event", events)
+	}
+
+	event := events[0]
+	if event.DomainName != "partner.com" || event.Previous.Categories[0].ID != 5 || event.Current.Categories[0].ID != 1 ||
+		len(event.Changes) != 2 || event.Time.IsZero() {
+		t.Errorf("event = %+v", event)
+	}
+
+	stored, err := monitor.Store.Load("partner.com")
+	if err != nil || stored.Categories[0].ID != 1 {
+		t.Errorf("stored result = %+v, error %v", stored, err)
+	}
+
+	var argErr *ArgError
+	if err := (&Monitor{}).Check(context.Background()); !errors.As(err, &argErr) {
+		t.Errorf("Check() without service error = %v, want ArgError", err)
+	}
+}
+
+// TestMonitorConfidenceThreshold tests the default and the negative confidence thresholds of Monitor.
+func TestMonitorConfidenceThreshold(t *testing.T) {
+	tests := []struct {
+		name       string
+		threshold  float64
+		confidence float64
+		want       int
+	}{
+		{name: "default below", confidence: 0.85},
+		{name: "default reached", confidence: 0.8, want: 1},
+		{name: "negative", threshold: -1, confidence: 0.89, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			confidence := 0.9
+
+			var events int
+
+			monitor := &Monitor{
+				Service: &stubService{get: func(ctx context.Context, domainName string) (*WCategorizationResponse, error) {
+					return &WCategorizationResponse{DomainName: domainName, Categories: []Category{{ID: 1, Confidence: confidence}}}, nil
+				}},
+				Domains:             []string{"example.com"},
+				ConfidenceThreshold: tt.threshold,
+				OnChange:            func(ChangeEvent) { events++ },
+			}
+
+			if err := monitor.Check(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			confidence = tt.confidence
+
+			if err := monitor.Check(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			if events != tt.want {
+				t.Errorf("events = %d, want %d", events, tt.want)
+			}
+		})
+	}
+}
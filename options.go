@@ -1,40 +1,232 @@
 package websitecategorization
 
 import (
+	"context"
 	"fmt"
+	"math"
 	"net/url"
+	"strconv"
 	"strings"
 )
 
-// Option adds parameters to the query.
-type Option func(v url.Values)
+// Endpoint is the set of Website Categorization API endpoints.
+type Endpoint int
+
+// API endpoints.
+const (
+	// EndpointDomain is the base path used by Get and GetRaw.
+	EndpointDomain Endpoint = 1 << iota
+
+	// EndpointCategories is the /categories path used by GetAllCategories and GetAllCategoriesRaw.
+	EndpointCategories
+
+	// EndpointAll is the set of all endpoints.
+	EndpointAll = EndpointDomain | EndpointCategories
+)
+
+// String returns the endpoint name.
+func (e Endpoint) String() string {
+	switch e {
+	case EndpointDomain:
+		return "domain"
+	case EndpointCategories:
+		return "categories"
+	case EndpointAll:
+		return "all"
+	}
+
+	return "Endpoint(" + strconv.Itoa(int(e)) + ")"
+}
+
+// Option adds parameters to the query. Every option applies to a specific set of endpoints.
+type Option struct {
+	name      string
+	endpoints Endpoint
+	apply     func(v url.Values)
+}
+
+// Name returns the query parameter set by the option.
+func (o Option) Name() string {
+	return o.name
+}
+
+// Endpoints returns the set of endpoints the option applies to.
+func (o Option) Endpoints() Endpoint {
+	return o.endpoints
+}
+
+// AppliesTo reports whether the option applies to the endpoint.
+func (o Option) AppliesTo(endpoint Endpoint) bool {
+	return o.endpoints&endpoint != 0
+}
+
+// Apply adds the option parameter to the query.
+func (o Option) Apply(v url.Values) {
+	if o.apply != nil {
+		o.apply(v)
+	}
+}
 
 var _ = []Option{
-	OptionOutputFormat("JSON"),
+	OptionOutputFormat(OutputFormatJSON),
 	OptionMinConfidence(0.55),
-	OptionOrder("ID"),
+	OptionOrder(OrderID),
+}
+
+// applyOptions adds the parameters of all options to the query.
+func applyOptions(v url.Values, opts []Option) {
+	for _, opt := range opts {
+		opt.Apply(v)
+	}
 }
 
+// OutputFormat is the API response format.
+type OutputFormat string
+
+// Supported output formats.
+const (
+	OutputFormatJSON OutputFormat = "JSON"
+	OutputFormatXML  OutputFormat = "XML"
+)
+
+// Order is the output order of the category directory.
+type Order string
+
+// Supported category orders.
+const (
+	// OrderABC orders categories alphabetically by the name field.
+	OrderABC Order = "ABC"
+
+	// OrderID orders categories by the id field.
+	OrderID Order = "ID"
+)
+
 // OptionOutputFormat sets Response output format JSON | XML. Default: JSON.
-func OptionOutputFormat(outputFormat string) Option {
-	return func(v url.Values) {
-		v.Set("outputFormat", strings.ToUpper(outputFormat))
+func OptionOutputFormat(outputFormat OutputFormat) Option {
+	return Option{
+		name:      "outputFormat",
+		endpoints: EndpointAll,
+		apply: func(v url.Values) {
+			v.Set("outputFormat", strings.ToUpper(string(outputFormat)))
+		},
 	}
 }
 
 // OptionMinConfidence sets The minimum confidence for the predictions. The higher this value the fewer
 // false-positive results will be returned. Acceptable values: 0.00 - 1.00. Default: 0.55.
+// It applies to domain lookups only.
 func OptionMinConfidence(value float64) Option {
-	return func(v url.Values) {
-		v.Set("minConfidence", fmt.Sprintf("%f", value))
+	return Option{
+		name:      "minConfidence",
+		endpoints: EndpointDomain,
+		apply: func(v url.Values) {
+			v.Set("minConfidence", fmt.Sprintf("%f", value))
+		},
 	}
 }
 
 // OptionOrder sets the categories output order (for GetAllCategories functions only).
 // ABC - output categories ordered alphabetically by the name field. ID - output categories ordered by the id field.
 // Acceptable values: ABC | ID Default: ID.
-func OptionOrder(order string) Option {
-	return func(v url.Values) {
-		v.Set("order", strings.ToUpper(order))
+func OptionOrder(order Order) Option {
+	return Option{
+		name:      "order",
+		endpoints: EndpointCategories,
+		apply: func(v url.Values) {
+			v.Set("order", strings.ToUpper(string(order)))
+		},
 	}
 }
+
+// ValidateOptions checks the values set by the options and returns ArgError for the first invalid one.
+// Requests with invalid options are not sent.
+func ValidateOptions(opts ...Option) error {
+	query := url.Values{}
+	applyOptions(query, opts)
+
+	return validateQuery(query)
+}
+
+// validateQuery checks the option values of the query.
+func validateQuery(query url.Values) error {
+	if values, ok := query["outputFormat"]; ok {
+		switch OutputFormat(values[0]) {
+		case OutputFormatJSON, OutputFormatXML:
+		default:
+			return &ArgError{"outputFormat", "must be " + string(OutputFormatJSON) + " or " + string(OutputFormatXML)}
+		}
+	}
+
+	if values, ok := query["order"]; ok {
+		switch Order(values[0]) {
+		case OrderABC, OrderID:
+		default:
+			return &ArgError{"order", "must be " + string(OrderABC) + " or " + string(OrderID)}
+		}
+	}
+
+	if values, ok := query["minConfidence"]; ok {
+		value, err := strconv.ParseFloat(values[0], 64)
+		if err != nil || math.IsNaN(value) || value < 0 || value > 1 {
+			return &ArgError{"minConfidence", "must be between 0.00 and 1.00"}
+		}
+	}
+
+	return nil
+}
+
+// requestedOutputFormat returns the output format the options request.
+func requestedOutputFormat(opts []Option) OutputFormat {
+	query := url.Values{}
+	applyOptions(query, opts)
+
+	if value := query.Get("outputFormat"); value != "" {
+		return OutputFormat(value)
+	}
+
+	return OutputFormatJSON
+}
+
+// defaultOptionsService is the WCategorizationService that adds the client default options to every request.
+// The options passed to a request override the default ones. Default options are added only to the requests
+// of the endpoints they apply to.
+type defaultOptionsService struct {
+	WCategorizationService
+
+	defaults []Option
+}
+
+// withDefaults returns the default options applicable to the endpoint followed by the request options.
+func (s *defaultOptionsService) withDefaults(endpoint Endpoint, opts []Option) []Option {
+	all := make([]Option, 0, len(s.defaults)+len(opts))
+
+	for _, opt := range s.defaults {
+		if opt.AppliesTo(endpoint) {
+			all = append(all, opt)
+		}
+	}
+
+	return append(all, opts...)
+}
+
+// Get returns parsed Website Categorization API response.
+func (s *defaultOptionsService) Get(ctx context.Context, domainName string, opts ...Option) (
+	*WCategorizationResponse, *Response, error) {
+	return s.WCategorizationService.Get(ctx, domainName, s.withDefaults(EndpointDomain, opts)...)
+}
+
+// GetRaw returns raw Website Categorization API response.
+func (s *defaultOptionsService) GetRaw(ctx context.Context, domainName string, opts ...Option) (*Response, error) {
+	return s.WCategorizationService.GetRaw(ctx, domainName, s.withDefaults(EndpointDomain, opts)...)
+}
+
+// GetAllCategories returns all possible categories.
+func (s *defaultOptionsService) GetAllCategories(ctx context.Context, opts ...Option) (
+	[]CategoryItem, *Response, error) {
+	return s.WCategorizationService.GetAllCategories(ctx, s.withDefaults(EndpointCategories, opts)...)
+}
+
+// GetAllCategoriesRaw returns all possible categories as a raw API response.
+func (s *defaultOptionsService) GetAllCategoriesRaw(ctx context.Context, opts ...Option) (*Response, error) {
+	return s.WCategorizationService.GetAllCategoriesRaw(ctx, s.withDefaults(EndpointCategories, opts)...)
+}
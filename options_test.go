@@ -1,6 +1,10 @@
 package websitecategorization
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"reflect"
 	"testing"
@@ -35,10 +39,195 @@ func TestOptions(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tt.option(tt.values)
+			tt.option.Apply(tt.values)
 			if got := tt.values.Encode(); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("Option() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+// TestValidateOptions tests the ValidateOptions function.
+func TestValidateOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []Option
+		wantErr string
+	}{
+		{
+			name:    "valid",
+			options: []Option{OptionOutputFormat("xml"), OptionOrder(OrderABC), OptionMinConfidence(1)},
+		},
+		{
+			name:    "output format",
+			options: []Option{OptionOutputFormat("CSV")},
+			wantErr: `invalid argument: "outputFormat" must be JSON or XML`,
+		},
+		{
+			name:    "order",
+			options: []Option{OptionOrder("name")},
+			wantErr: `invalid argument: "order" must be ABC or ID`,
+		},
+		{
+			name:    "min confidence",
+			options: []Option{OptionMinConfidence(55)},
+			wantErr: `invalid argument: "minConfidence" must be between 0.00 and 1.00`,
+		},
+		{
+			name:    "overridden value",
+			options: []Option{OptionMinConfidence(-1), OptionMinConfidence(0)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateOptions(tt.options...)
+			if (err != nil || tt.wantErr != "") && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("ValidateOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestClientOptions tests that invalid options are not sent and default options are added to requests.
+func TestClientOptions(t *testing.T) {
+	var queries []url.Values
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		queries = append(queries, req.URL.Query())
+		_, _ = w.Write([]byte(`{"domainName":"whoisxmlapi.com","categories":[]}`))
+	}))
+	defer server.Close()
+
+	apiURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewClient(apiKey, ClientParams{
+		HTTPClient:             server.Client(),
+		WCategorizationBaseURL: apiURL,
+		Cache:                  NewMemoryCache(0, 0),
+		CacheMinConfidence:     0.1,
+		DefaultOptions:         []Option{OptionMinConfidence(0.7), OptionOutputFormat(OutputFormatXML)},
+	})
+
+	ctx := context.Background()
+
+	var argErr *ArgError
+
+	if _, _, err = client.Get(ctx, "whoisxmlapi.com", OptionMinConfidence(1.5)); !errors.As(err, &argErr) {
+		t.Errorf("Client.Get() error = %v, want ArgError", err)
+	}
+
+	if _, err = client.GetRaw(ctx, "whoisxmlapi.com", OptionOutputFormat("HTML")); !errors.As(err, &argErr) {
+		t.Errorf("Client.GetRaw() error = %v, want ArgError", err)
+	}
+
+	if len(queries) != 0 {
+		t.Fatalf("requests with invalid options must not be sent, got %v", queries)
+	}
+
+	if _, err = client.GetRaw(ctx, "whoisxmlapi.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = client.GetRaw(ctx, "whoisxmlapi.com", OptionMinConfidence(0.9)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(queries) != 2 || queries[0].Get("minConfidence") != "0.700000" || queries[0].Get("outputFormat") != "XML" ||
+		queries[1].Get("minConfidence") != "0.900000" {
+		t.Errorf("API queries = %v, want default options overridden by request options", queries)
+	}
+}
+
+// TestOptionEndpoints tests handling of options inapplicable to the endpoint.
+func TestOptionEndpoints(t *testing.T) {
+	var queries []url.Values
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		queries = append(queries, req.URL.Query())
+
+		if req.URL.Path == "/categories" {
+			_, _ = w.Write([]byte(`[{"id":0,"name":"Uncategorized"}]`))
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"domainName":"whoisxmlapi.com","categories":[]}`))
+	}))
+	defer server.Close()
+
+	newClient := func(strict bool, warning func(error)) *Client {
+		apiURL, err := url.Parse(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return NewClient(apiKey, ClientParams{
+			HTTPClient:             server.Client(),
+			WCategorizationBaseURL: apiURL,
+			DefaultOptions:         []Option{OptionMinConfidence(0.7), OptionOrder(OrderABC)},
+			StrictOptions:          strict,
+			OptionWarning:          warning,
+		})
+	}
+
+	ctx := context.Background()
+
+	var argErr *ArgError
+
+	strict := newClient(true, nil)
+
+	if _, _, err := strict.Get(ctx, "whoisxmlapi.com", OptionOrder(OrderID)); !errors.As(err, &argErr) ||
+		err.Error() != `invalid argument: "order" is not applicable to the domain endpoint` {
+		t.Errorf("Client.Get() error = %v, want ArgError", err)
+	}
+
+	if _, _, err := strict.GetAllCategories(ctx, OptionMinConfidence(0.1)); !errors.As(err, &argErr) {
+		t.Errorf("Client.GetAllCategories() error = %v, want ArgError", err)
+	}
+
+	if len(queries) != 0 {
+		t.Fatalf("requests with inapplicable options must not be sent, got %v", queries)
+	}
+
+	if _, _, err := strict.GetAllCategories(ctx); err != nil {
+		t.Errorf("Client.GetAllCategories() error = %v, default options must be added to applicable endpoints only", err)
+	}
+
+	var warnings []error
+
+	lenient := newClient(false, func(err error) { warnings = append(warnings, err) })
+
+	if _, _, err := lenient.Get(ctx, "whoisxmlapi.com", OptionOrder(OrderID)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(warnings) != 1 || len(queries) != 2 || queries[1].Get("order") != "" || queries[1].Get("minConfidence") == "" {
+		t.Errorf("warnings = %v, queries = %v", warnings, queries)
+	}
+}
+
+// TestOptionAppliesTo tests the endpoints of the options.
+func TestOptionAppliesTo(t *testing.T) {
+	tests := []struct {
+		option Option
+		want   Endpoint
+	}{
+		{OptionOutputFormat(OutputFormatJSON), EndpointAll},
+		{OptionMinConfidence(0.5), EndpointDomain},
+		{OptionOrder(OrderABC), EndpointCategories},
+		{Option{}, 0},
+	}
+
+	for _, tt := range tests {
+		if got := tt.option.Endpoints(); got != tt.want {
+			t.Errorf("%q Option.Endpoints() = %v, want %v", tt.option.Name(), got, tt.want)
+		}
+
+		if tt.option.AppliesTo(EndpointDomain) != (tt.want&EndpointDomain != 0) {
+			t.Errorf("%q Option.AppliesTo(%v) returned unexpected result", tt.option.Name(), EndpointDomain)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package websitecategorization
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ResultSource is the origin of WCategorizationResponse that is not returned by the API.
+type ResultSource string
+
+// SourceOverride marks responses taken from Overrides.
+const SourceOverride ResultSource = "override"
+
+// OverrideRule assigns fixed categories to a domain.
+type OverrideRule struct {
+	// Domain is the domain name, e.g. "intranet.example.com", or the wildcard suffix matching all subdomains
+	// of the domain, e.g. "*.example.com". Exact domains take precedence over wildcards, longer wildcards
+	// take precedence over shorter ones.
+	Domain string `json:"domain"`
+
+	// Categories are returned for the matching domains. Zero confidence is replaced with 1.
+	Categories []Category `json:"categories,omitempty"`
+
+	// Never makes the matching domains never categorized: they are not sent to the API
+	// and the response has no categories.
+	Never bool `json:"never,omitempty"`
+}
+
+// overrideSet is the indexed set of override rules.
+type overrideSet struct {
+	exact    map[string]OverrideRule
+	wildcard map[string]OverrideRule
+}
+
+// Overrides is the user-maintained table of domains with authoritative categories, consulted before the API.
+// It is safe for concurrent use.
+type Overrides struct {
+	mu  sync.RWMutex
+	set overrideSet
+
+	// path, modTime and size identify the loaded file.
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+// NewOverrides creates Overrides with the rules.
+func NewOverrides(rules []OverrideRule) (*Overrides, error) {
+	set, err := newOverrideSet(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Overrides{set: set}, nil
+}
+
+// LoadOverrides creates Overrides with the rules loaded from the JSON file with the list of OverrideRule.
+// The file can be reloaded with Reload or Watch.
+func LoadOverrides(path string) (*Overrides, error) {
+	o := &Overrides{path: path}
+
+	if err := o.Reload(); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// newOverrideSet indexes the rules.
+func newOverrideSet(rules []OverrideRule) (overrideSet, error) {
+	set := overrideSet{
+		exact:    make(map[string]OverrideRule),
+		wildcard: make(map[string]OverrideRule),
+	}
+
+	for i, rule := range rules {
+		domain := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(rule.Domain)), ".")
+
+		target := set.exact
+		if strings.HasPrefix(domain, "*.") {
+			domain, target = domain[2:], set.wildcard
+		}
+
+		if domain == "" || strings.Contains(domain, "*") {
+			return set, &ArgError{fmt.Sprintf("rules[%d].domain", i), "must be a domain name or *.domain"}
+		}
+
+		if rule.Never && len(rule.Categories) > 0 {
+			return set, &ArgError{fmt.Sprintf("rules[%d]", i), "can not have both categories and never"}
+		}
+
+		if _, ok := target[domain]; ok {
+			return set, &ArgError{fmt.Sprintf("rules[%d].domain", i), "is duplicated: " + rule.Domain}
+		}
+
+		categories := make([]Category, len(rule.Categories))
+		for j, category := range rule.Categories {
+			if category.Confidence == 0 {
+				category.Confidence = 1
+			}
+
+			categories[j] = category
+		}
+
+		rule.Categories = categories
+		target[domain] = rule
+	}
+
+	return set, nil
+}
+
+// Reload loads the rules from the file again. The previous rules are kept if the file is invalid.
+func (o *Overrides) Reload() error {
+	if o.path == "" {
+		return errors.New("overrides are not loaded from a file")
+	}
+
+	info, err := os.Stat(o.path)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(o.path)
+	if err != nil {
+		return err
+	}
+
+	var rules []OverrideRule
+	if err = json.Unmarshal(data, &rules); err != nil {
+		return fmt.Errorf("cannot parse overrides: %w", err)
+	}
+
+	set, err := newOverrideSet(rules)
+	if err != nil {
+		return fmt.Errorf("invalid overrides: %w", err)
+	}
+
+	o.mu.Lock()
+	o.set = set
+	o.mu.Unlock()
+
+	o.seen(info)
+
+	return nil
+}
+
+// Watch reloads the rules whenever the file changes, checking it every interval until the context is done.
+// Reload errors are passed to onError if it is not nil, the previous rules stay in effect.
+func (o *Overrides) Watch(ctx context.Context, interval time.Duration, onError func(err error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(o.path)
+		if err != nil || !o.changed(info) {
+			continue
+		}
+
+		if err = o.Reload(); err != nil {
+			// The same broken file is not reported again until it changes.
+			o.seen(info)
+
+			if onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// changed reports whether the file differs from the last seen one.
+func (o *Overrides) changed(info os.FileInfo) bool {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return !info.ModTime().Equal(o.modTime) || info.Size() != o.size
+}
+
+// seen remembers the file version.
+func (o *Overrides) seen(info os.FileInfo) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.modTime = info.ModTime()
+	o.size = info.Size()
+}
+
+// Lookup returns the rule matching the domain name.
+func (o *Overrides) Lookup(domainName string) (OverrideRule, bool) {
+	name := strings.TrimSuffix(strings.ToLower(domainName), ".")
+
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	if rule, ok := o.set.exact[name]; ok {
+		return rule, true
+	}
+
+	for {
+		dot := strings.IndexByte(name, '.')
+		if dot < 0 {
+			return OverrideRule{}, false
+		}
+
+		name = name[dot+1:]
+
+		if rule, ok := o.set.wildcard[name]; ok {
+			return rule, true
+		}
+	}
+}
+
+// Get returns the response for the domain name if it matches a rule. The response has Source set to
+// SourceOverride. Domains with categories are reported as responded, never categorized domains are not.
+func (o *Overrides) Get(domainName string) (*WCategorizationResponse, bool) {
+	rule, ok := o.Lookup(domainName)
+	if !ok {
+		return nil, false
+	}
+
+	resp := &WCategorizationResponse{
+		DomainName:       domainName,
+		Categories:       make([]Category, len(rule.Categories)),
+		WebsiteResponded: !rule.Never,
+		Source:           SourceOverride,
+	}
+
+	copy(resp.Categories, rule.Categories)
+
+	return resp, true
+}
+
+// WithOverrides returns the Decorator that answers requests for the domains matching the overrides without
+// calling the API. Get returns nil Response for them. GetRaw returns the JSON encoded response with the
+// status code 200, requests with OutputFormatXML fail with ArgError.
+func WithOverrides(overrides *Overrides) Decorator {
+	return func(service WCategorizationService) WCategorizationService {
+		return &overridingService{WCategorizationService: service, overrides: overrides}
+	}
+}
+
+// overridingService is the WCategorizationService that serves overridden domains.
+type overridingService struct {
+	WCategorizationService
+
+	overrides *Overrides
+}
+
+// Get returns the overridden or the API response.
+func (s *overridingService) Get(ctx context.Context, domainName string, opts ...Option) (
+	*WCategorizationResponse, *Response, error) {
+	if resp, ok := s.overrides.Get(domainName); ok {
+		return resp, nil, nil
+	}
+
+	return s.WCategorizationService.Get(ctx, domainName, opts...)
+}
+
+// GetRaw returns the overridden or the API response.
+func (s *overridingService) GetRaw(ctx context.Context, domainName string, opts ...Option) (*Response, error) {
+	resp, ok := s.overrides.Get(domainName)
+	if !ok {
+		return s.WCategorizationService.GetRaw(ctx, domainName, opts...)
+	}
+
+	if requestedOutputFormat(opts) == OutputFormatXML {
+		return nil, &ArgError{"outputFormat", "must be " + string(OutputFormatJSON) + " for overridden domains"}
+	}
+
+	return syntheticResponse(resp)
+}
+
+// syntheticResponse returns the JSON encoded response as if it is returned by the API.
+func syntheticResponse(resp *WCategorizationResponse) (*Response, error) {
+	body, err := json.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", mediaType)
+
+	return &Response{
+		Response: &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			ContentLength: int64(len(body)),
+		},
+		Body: body,
+	}, nil
+}
@@ -0,0 +1,187 @@
+package websitecategorization
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestOverridesLookup tests the precedence of exact domains and wildcards.
+func TestOverridesLookup(t *testing.T) {
+	overrides, err := NewOverrides([]OverrideRule{
+		{Domain: "*.example.com", Categories: []Category{{ID: 1}}},
+		{Domain: "*.corp.example.com", Never: true},
+		{Domain: "wiki.corp.example.com.", Categories: []Category{{ID: 2}}},
+		{Domain: "Partner.org", Categories: []Category{{ID: 3, Confidence: 0.7}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		domainName string
+		wantOK     bool
+		wantRule   string
+	}{
+		{"www.example.com", true, "*.example.com"},
+		{"example.com", false, ""},
+		{"mail.corp.example.com", true, "*.corp.example.com"},
+		{"WIKI.corp.example.com", true, "wiki.corp.example.com."},
+		{"partner.org.", true, "Partner.org"},
+		{"www.partner.org", false, ""},
+		{"example.org", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.domainName, func(t *testing.T) {
+			rule, ok := overrides.Lookup(tt.domainName)
+			if ok != tt.wantOK || rule.Domain != tt.wantRule {
+				t.Errorf("Lookup() = %q, %v, want %q, %v", rule.Domain, ok, tt.wantRule, tt.wantOK)
+			}
+		})
+	}
+}
+
+// TestNewOverridesInvalid tests the validation of override rules.
+func TestNewOverridesInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []OverrideRule
+	}{
+		{"empty domain", []OverrideRule{{Domain: ""}}},
+		{"inner wildcard", []OverrideRule{{Domain: "www.*.com"}}},
+		{"never with categories", []OverrideRule{{Domain: "a.com", Never: true, Categories: []Category{{ID: 1}}}}},
+		{"duplicate", []OverrideRule{{Domain: "a.com", Never: true}, {Domain: "A.com.", Never: true}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var argErr *ArgError
+			if _, err := NewOverrides(tt.rules); !errors.As(err, &argErr) {
+				t.Errorf("NewOverrides() error = %v, want ArgError", err)
+			}
+		})
+	}
+}
+
+// TestWithOverrides tests that overridden domains are answered without the API.
+func TestWithOverrides(t *testing.T) {
+	overrides, err := NewOverrides([]OverrideRule{
+		{Domain: "partner.org", Categories: []Category{{ID: 3, Name: "Business"}}},
+		{Domain: "*.internal", Never: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fake := &fakeService{}
+	service := Decorate(fake, WithOverrides(overrides))
+
+	ctx := context.Background()
+
+	resp, rawResp, err := service.Get(ctx, "partner.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rawResp != nil || resp.Source != SourceOverride || !resp.WebsiteResponded || !resp.HasCategory(3) {
+		t.Errorf("Get() = %+v, %v", resp, rawResp)
+	}
+
+	if resp.Categories[0].Confidence != 1 {
+		t.Errorf("confidence = %v, want 1", resp.Categories[0].Confidence)
+	}
+
+	resp, _, err = service.Get(ctx, "git.internal")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Source != SourceOverride || resp.WebsiteResponded || len(resp.Categories) != 0 {
+		t.Errorf("Get() = %+v", resp)
+	}
+
+	raw, err := service.GetRaw(ctx, "partner.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded WCategorizationResponse
+	if err = json.Unmarshal(raw.Body, &decoded); err != nil || raw.StatusCode != 200 || !decoded.HasCategory(3) {
+		t.Errorf("GetRaw() = %d %s, error %v", raw.StatusCode, raw.Body, err)
+	}
+
+	var argErr *ArgError
+	if _, err = service.GetRaw(ctx, "partner.org", OptionOutputFormat(OutputFormatXML)); !errors.As(err, &argErr) {
+		t.Errorf("GetRaw() with XML error = %v, want ArgError", err)
+	}
+
+	if len(fake.calls) != 0 {
+		t.Errorf("API calls = %v, want none", fake.calls)
+	}
+
+	resp, _, err = service.Get(ctx, "example.com")
+	if err != nil || resp.Source != "" || len(fake.calls) != 1 {
+		t.Errorf("Get() = %+v, error %v, calls %v", resp, err, fake.calls)
+	}
+}
+
+// TestOverridesWatch tests that the rules are reloaded when the file changes and kept when it breaks.
+func TestOverridesWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "overrides.json")
+
+	write := func(data string) {
+		t.Helper()
+
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`[{"domain": "a.com", "never": true}]`)
+
+	overrides, err := LoadOverrides(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errs := make(chan error, 10)
+
+	go overrides.Watch(ctx, time.Millisecond, func(err error) { errs <- err })
+
+	waitFor := func(domainName string, want bool) {
+		t.Helper()
+
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if _, ok := overrides.Lookup(domainName); ok == want {
+				return
+			}
+
+			time.Sleep(time.Millisecond)
+		}
+
+		t.Fatalf("Lookup(%q) != %v after reload", domainName, want)
+	}
+
+	write(`[{"domain": "b.com", "categories": [{"id": 1}]}, {"domain": "*.c.com", "never": true}]`)
+	waitFor("b.com", true)
+	waitFor("a.com", false)
+
+	write(`[{"domain": "broken"`)
+
+	select {
+	case <-errs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("reload error is not reported")
+	}
+
+	if _, ok := overrides.Lookup("b.com"); !ok {
+		t.Error("rules are lost after a failed reload")
+	}
+}
@@ -0,0 +1,107 @@
+package websitecategorization
+
+// Verdict is the outcome of applying Policy to a categorization result.
+type Verdict int
+
+const (
+	// VerdictAllow means that the website is allowed.
+	VerdictAllow Verdict = iota
+
+	// VerdictBlock means that the website is blocked.
+	VerdictBlock
+)
+
+// String returns the verdict name.
+func (v Verdict) String() string {
+	if v == VerdictBlock {
+		return "block"
+	}
+
+	return "allow"
+}
+
+// Policy decides whether a website is allowed according to its categories.
+// The zero value allows everything.
+type Policy struct {
+	// Deny is the list of category IDs that cause a website to be blocked.
+	Deny []int `json:"deny,omitempty"`
+
+	// Allow is the list of category IDs that let a website through even if it matches Deny.
+	Allow []int `json:"allow,omitempty"`
+
+	// MinConfidence is the minimum confidence of a category to be taken into account.
+	MinConfidence float64 `json:"minConfidence,omitempty"`
+
+	// HeuristicMinConfidence is the minimum confidence of a category guessed by HeuristicClassifier
+	// to be taken into account. Zero means that MinConfidence applies to guessed categories as well.
+	HeuristicMinConfidence float64 `json:"heuristicMinConfidence,omitempty"`
+
+	// BlockUncategorized blocks websites that have no categories above MinConfidence.
+	BlockUncategorized bool `json:"blockUncategorized,omitempty"`
+}
+
+// Decision is the result of applying Policy to a categorization result.
+type Decision struct {
+	// Verdict is the policy outcome.
+	Verdict Verdict
+
+	// Matched is the list of categories that caused the verdict.
+	// It is empty when the verdict is based on the absence of categories.
+	Matched []Category
+}
+
+// Blocked reports whether the website is blocked.
+func (d Decision) Blocked() bool {
+	return d.Verdict == VerdictBlock
+}
+
+// Decide applies the policy to the categorization result.
+func (p *Policy) Decide(resp *WCategorizationResponse) Decision {
+	var allowed, denied []Category
+
+	var considered int
+
+	if resp != nil {
+		minConfidence := p.MinConfidence
+		if resp.Source == SourceHeuristic && p.HeuristicMinConfidence > 0 {
+			minConfidence = p.HeuristicMinConfidence
+		}
+
+		for _, category := range resp.Categories {
+			if category.Confidence < minConfidence {
+				continue
+			}
+
+			considered++
+
+			switch {
+			case containsID(p.Allow, category.ID):
+				allowed = append(allowed, category)
+			case containsID(p.Deny, category.ID):
+				denied = append(denied, category)
+			}
+		}
+	}
+
+	switch {
+	case len(allowed) > 0:
+		return Decision{Verdict: VerdictAllow, Matched: allowed}
+	case len(denied) > 0:
+		return Decision{Verdict: VerdictBlock, Matched: denied}
+	case considered == 0 && p.BlockUncategorized:
+		return Decision{Verdict: VerdictBlock}
+	}
+
+	return Decision{Verdict: VerdictAllow}
+}
+
+// containsID reports whether the id is in the list.
+func containsID(ids []int, id int) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,74 @@
+package websitecategorization
+
+import (
+	"testing"
+)
+
+// TestPolicyDecide tests the Policy.Decide function.
+func TestPolicyDecide(t *testing.T) {
+	policy := Policy{
+		Deny:               []int{10, 11},
+		Allow:              []int{5},
+		MinConfidence:      0.5,
+		BlockUncategorized: true,
+	}
+
+	tests := []struct {
+		name        string
+		categories  []Category
+		want        Verdict
+		wantMatched int
+	}{
+		{
+			name:       "no denied categories",
+			categories: []Category{{ID: 1, Confidence: 0.9}},
+			want:       VerdictAllow,
+		},
+		{
+			name:        "denied category",
+			categories:  []Category{{ID: 1, Confidence: 0.9}, {ID: 10, Confidence: 0.6}, {ID: 11, Confidence: 0.7}},
+			want:        VerdictBlock,
+			wantMatched: 2,
+		},
+		{
+			name:        "allowed category wins",
+			categories:  []Category{{ID: 10, Confidence: 0.9}, {ID: 5, Confidence: 0.6}},
+			want:        VerdictAllow,
+			wantMatched: 1,
+		},
+		{
+			name:       "denied category below confidence",
+			categories: []Category{{ID: 1, Confidence: 0.9}, {ID: 10, Confidence: 0.4}},
+			want:       VerdictAllow,
+		},
+		{
+			name:       "uncategorized",
+			categories: []Category{{ID: 10, Confidence: 0.4}},
+			want:       VerdictBlock,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := policy.Decide(&WCategorizationResponse{Categories: tt.categories})
+			if got.Verdict != tt.want || len(got.Matched) != tt.wantMatched {
+				t.Errorf("Policy.Decide() = %v, want %v with %d matched categories", got, tt.want, tt.wantMatched)
+			}
+		})
+	}
+
+	heuristic := &WCategorizationResponse{Categories: []Category{{ID: 10, Confidence: 0.3}}, Source: SourceHeuristic}
+
+	if got := policy.Decide(heuristic); got.Verdict != VerdictBlock || len(got.Matched) != 0 {
+		t.Errorf("Policy.Decide() for guessed categories = %v, want uncategorized", got)
+	}
+
+	policy.HeuristicMinConfidence = 0.2
+
+	if got := policy.Decide(heuristic); got.Verdict != VerdictBlock || len(got.Matched) != 1 {
+		t.Errorf("Policy.Decide() with HeuristicMinConfidence = %v, want denied category", got)
+	}
+
+	if got := (&Policy{}).Decide(nil); got.Blocked() {
+		t.Error("zero Policy must allow everything")
+	}
+}
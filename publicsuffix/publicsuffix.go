@@ -0,0 +1,192 @@
+// Package publicsuffix finds public suffixes and registrable domains of host names
+// according to the Public Suffix List, see https://publicsuffix.org.
+//
+// A copy of the list is embedded in the package. A newer list downloaded from
+// https://publicsuffix.org/list/public_suffix_list.dat can be loaded with Load.
+package publicsuffix
+
+import (
+	"bufio"
+	_ "embed" // The default list is embedded.
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ErrPublicSuffix is returned when the name is a public suffix itself and has no registrable domain.
+var ErrPublicSuffix = errors.New("name is a public suffix")
+
+// ErrInvalidName is returned for empty names and names with empty labels.
+var ErrInvalidName = errors.New("invalid domain name")
+
+//go:embed public_suffix_list.dat
+var embeddedList string
+
+// rule flags of the suffix.
+const (
+	ruleExact = 1 << iota
+	ruleWildcard
+	ruleException
+)
+
+// List is the parsed Public Suffix List. It is safe for concurrent use.
+type List struct {
+	// rules maps suffixes to their rule flags. A wildcard rule *.example is stored under "example".
+	rules map[string]uint8
+
+	// count is the number of rules.
+	count int
+}
+
+var (
+	defaultOnce sync.Once
+	defaultList *List
+)
+
+// Default returns the list embedded in the package.
+func Default() *List {
+	defaultOnce.Do(func() {
+		var err error
+
+		defaultList, err = Parse(strings.NewReader(embeddedList))
+		if err != nil {
+			panic(err)
+		}
+	})
+
+	return defaultList
+}
+
+// Load reads the list from the file in the public_suffix_list.dat format.
+func Load(path string) (*List, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Parse(file)
+}
+
+// Parse reads the list in the public_suffix_list.dat format. Both ICANN and private domains are included.
+func Parse(r io.Reader) (*List, error) {
+	l := &List{rules: make(map[string]uint8)}
+
+	scanner := bufio.NewScanner(r)
+
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "//") {
+			continue
+		}
+
+		rule := toASCII(strings.ToLower(fields[0]))
+
+		var flag uint8
+
+		switch {
+		case strings.HasPrefix(rule, "!"):
+			rule, flag = rule[1:], ruleException
+		case strings.HasPrefix(rule, "*."):
+			rule, flag = rule[2:], ruleWildcard
+		default:
+			flag = ruleExact
+		}
+
+		if rule == "" || strings.Contains(rule, "*") {
+			return nil, fmt.Errorf("line %d: unsupported rule %q", line, fields[0])
+		}
+
+		if l.rules[rule]&flag == 0 {
+			l.rules[rule] |= flag
+			l.count++
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if l.count == 0 {
+		return nil, errors.New("the list has no rules")
+	}
+
+	return l, nil
+}
+
+// Len returns the number of rules in the list.
+func (l *List) Len() int {
+	return l.count
+}
+
+// PublicSuffix returns the public suffix of the name, e.g. "co.uk" for "www.example.co.uk".
+// Names not matched by any rule have the top-level label as their public suffix.
+func (l *List) PublicSuffix(name string) (string, error) {
+	name, err := normalize(name)
+	if err != nil {
+		return "", err
+	}
+
+	return l.publicSuffix(name), nil
+}
+
+// RegistrableDomain returns the public suffix of the name with one more label,
+// e.g. "example.co.uk" for "a.b.cdn.example.co.uk". It returns ErrPublicSuffix if there is no such label.
+func (l *List) RegistrableDomain(name string) (string, error) {
+	name, err := normalize(name)
+	if err != nil {
+		return "", err
+	}
+
+	suffix := l.publicSuffix(name)
+	if suffix == name {
+		return "", fmt.Errorf("%w: %s", ErrPublicSuffix, name)
+	}
+
+	rest := name[:len(name)-len(suffix)-1]
+
+	return name[strings.LastIndexByte(rest, '.')+1:], nil
+}
+
+// publicSuffix returns the public suffix of the normalized name. The longest matching rule wins,
+// exception rules take precedence over the wildcard rules they are the exceptions of.
+func (l *List) publicSuffix(name string) string {
+	for candidate := name; ; {
+		dot := strings.IndexByte(candidate, '.')
+
+		flags := l.rules[candidate]
+		if flags&ruleException != 0 {
+			return candidate[dot+1:]
+		}
+
+		if flags&ruleExact != 0 {
+			return candidate
+		}
+
+		if dot < 0 {
+			// The default rule "*".
+			return candidate
+		}
+
+		parent := candidate[dot+1:]
+		if l.rules[parent]&ruleWildcard != 0 {
+			return candidate
+		}
+
+		candidate = parent
+	}
+}
+
+// normalize returns the lowercase ASCII form of the name without the trailing dot.
+func normalize(name string) (string, error) {
+	name = toASCII(strings.ToLower(strings.TrimSuffix(name, ".")))
+
+	if name == "" || strings.HasPrefix(name, ".") || strings.Contains(name, "..") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+
+	return name, nil
+}
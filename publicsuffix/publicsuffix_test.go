@@ -0,0 +1,154 @@
+package publicsuffix
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRegistrableDomain tests the embedded list with the cases of the Public Suffix List test suite.
+func TestRegistrableDomain(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr error
+	}{
+		{name: "", wantErr: ErrInvalidName},
+		{name: ".example.com", wantErr: ErrInvalidName},
+		{name: "a..example.com", wantErr: ErrInvalidName},
+		{name: "com", wantErr: ErrPublicSuffix},
+		{name: "example.com", want: "example.com"},
+		{name: "b.example.com", want: "example.com"},
+		{name: "a.b.example.com.", want: "example.com"},
+		{name: "WWW.Example.COM", want: "example.com"},
+		{name: "uk", wantErr: ErrPublicSuffix},
+		{name: "co.uk", wantErr: ErrPublicSuffix},
+		{name: "a.b.cdn.example.co.uk", want: "example.co.uk"},
+		{name: "example", wantErr: ErrPublicSuffix},
+		{name: "b.example.example", want: "example.example"},
+		{name: "ck", wantErr: ErrPublicSuffix},
+		{name: "test.ck", wantErr: ErrPublicSuffix},
+		{name: "b.test.ck", want: "b.test.ck"},
+		{name: "a.b.test.ck", want: "b.test.ck"},
+		{name: "www.ck", want: "www.ck"},
+		{name: "www.www.ck", want: "www.ck"},
+		{name: "kyoto.jp", wantErr: ErrPublicSuffix},
+		{name: "test.kyoto.jp", want: "test.kyoto.jp"},
+		{name: "ide.kyoto.jp", wantErr: ErrPublicSuffix},
+		{name: "b.ide.kyoto.jp", want: "b.ide.kyoto.jp"},
+		{name: "c.kobe.jp", wantErr: ErrPublicSuffix},
+		{name: "b.c.kobe.jp", want: "b.c.kobe.jp"},
+		{name: "city.kobe.jp", want: "city.kobe.jp"},
+		{name: "www.city.kobe.jp", want: "city.kobe.jp"},
+		{name: "shop.example.github.io", want: "example.github.io"},
+		{name: "食狮.公司.cn", want: "xn--85x722f.xn--55qx5d.cn"},
+		{name: "www.xn--85x722f.xn--55qx5d.cn", want: "xn--85x722f.xn--55qx5d.cn"},
+		{name: "公司.cn", wantErr: ErrPublicSuffix},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Default().RegistrableDomain(tt.name)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RegistrableDomain() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("RegistrableDomain() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPublicSuffix tests the public suffixes of matched and unmatched names.
+func TestPublicSuffix(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"www.example.co.uk", "co.uk"},
+		{"example.unknowntld", "unknowntld"},
+		{"a.b.test.ck", "test.ck"},
+		{"www.ck", "ck"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Default().PublicSuffix(tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("PublicSuffix() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestLoad tests loading the list from a file.
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "list.dat")
+
+	const list = `// ===BEGIN ICANN DOMAINS===
+com
+*.test
+!www.test
+
+// ===BEGIN PRIVATE DOMAINS===
+cdn.example.com   trailing text is ignored
+`
+
+	if err := os.WriteFile(path, []byte(list), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if l.Len() != 4 {
+		t.Errorf("Len() = %d, want 4", l.Len())
+	}
+
+	for name, want := range map[string]string{
+		"a.b.cdn.example.com": "b.cdn.example.com",
+		"a.example.com":       "example.com",
+		"a.b.c.test":          "b.c.test",
+		"a.www.test":          "www.test",
+	} {
+		if got, _ := l.RegistrableDomain(name); got != want {
+			t.Errorf("RegistrableDomain(%q) = %q, want %q", name, got, want)
+		}
+	}
+
+	if _, err = Parse(strings.NewReader("a.*.b\n")); err == nil {
+		t.Error("Parse() must fail on inner wildcards")
+	}
+
+	if _, err = Parse(strings.NewReader("// comment only\n")); err == nil {
+		t.Error("Parse() must fail on empty lists")
+	}
+}
+
+// TestPunycode tests the encoding of internationalized labels.
+func TestPunycode(t *testing.T) {
+	tests := []struct {
+		label string
+		want  string
+	}{
+		{"bücher", "bcher-kva"},
+		{"münchen", "mnchen-3ya"},
+		{"公司", "55qx5d"},
+		{"食狮", "85x722f"},
+		{"他们为什么不说中文", "ihqwcrb4cv8a8dqg056pqjye"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			if got := punycode(tt.label); got != tt.want {
+				t.Errorf("punycode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
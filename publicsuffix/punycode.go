@@ -0,0 +1,152 @@
+package publicsuffix
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Punycode parameters, see RFC 3492.
+const (
+	punyBase        = 36
+	punyTMin        = 1
+	punyTMax        = 26
+	punySkew        = 38
+	punyDamp        = 700
+	punyInitialBias = 72
+	punyInitialN    = 128
+
+	// acePrefix is the prefix of the ASCII form of an internationalized label.
+	acePrefix = "xn--"
+)
+
+// toASCII converts every non-ASCII label of the domain name to its Punycode form.
+// The labels are not mapped or validated as IDNA requires, the names are expected to be lowercase already.
+func toASCII(name string) string {
+	if isASCII(name) {
+		return name
+	}
+
+	labels := strings.Split(name, ".")
+	for i, label := range labels {
+		if !isASCII(label) {
+			labels[i] = acePrefix + punycode(label)
+		}
+	}
+
+	return strings.Join(labels, ".")
+}
+
+// isASCII reports whether the string consists of ASCII characters only.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+
+	return true
+}
+
+// punycode encodes the label according to RFC 3492.
+func punycode(label string) string {
+	runes := []rune(label)
+
+	var out strings.Builder
+
+	for _, r := range runes {
+		if r < utf8.RuneSelf {
+			out.WriteRune(r)
+		}
+	}
+
+	basic := out.Len()
+	handled := basic
+
+	if basic > 0 {
+		out.WriteByte('-')
+	}
+
+	n := rune(punyInitialN)
+	delta := 0
+	bias := punyInitialBias
+
+	for handled < len(runes) {
+		m := rune(utf8.MaxRune)
+		for _, r := range runes {
+			if r >= n && r < m {
+				m = r
+			}
+		}
+
+		delta += int(m-n) * (handled + 1)
+		n = m
+
+		for _, r := range runes {
+			if r < n {
+				delta++
+			}
+
+			if r != n {
+				continue
+			}
+
+			q := delta
+
+			for k := punyBase; ; k += punyBase {
+				t := k - bias
+
+				switch {
+				case t < punyTMin:
+					t = punyTMin
+				case t > punyTMax:
+					t = punyTMax
+				}
+
+				if q < t {
+					break
+				}
+
+				out.WriteByte(punyDigit(t + (q-t)%(punyBase-t)))
+				q = (q - t) / (punyBase - t)
+			}
+
+			out.WriteByte(punyDigit(q))
+			bias = punyAdapt(delta, handled+1, handled == basic)
+			delta = 0
+			handled++
+		}
+
+		delta++
+		n++
+	}
+
+	return out.String()
+}
+
+// punyDigit returns the character representing the digit.
+func punyDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+
+	return byte('0' + d - 26)
+}
+
+// punyAdapt returns the new bias.
+func punyAdapt(delta, points int, first bool) int {
+	if first {
+		delta /= punyDamp
+	} else {
+		delta /= 2
+	}
+
+	delta += delta / points
+
+	k := 0
+	for delta > ((punyBase-punyTMin)*punyTMax)/2 {
+		delta /= punyBase - punyTMin
+		k += punyBase
+	}
+
+	return k + (punyBase-punyTMin+1)*delta/(delta+punySkew)
+}
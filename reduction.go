@@ -0,0 +1,93 @@
+package websitecategorization
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/whois-api-llc/website-categorization-go/publicsuffix"
+)
+
+// DomainReduction is the policy of requesting the registrable domain (eTLD+1) instead of the full host name,
+// so a.b.cdn.example.co.uk is requested as example.co.uk. Subdomains of the same website are billed once
+// and share the cache entry.
+type DomainReduction struct {
+	// List is the Public Suffix List. Default: the list embedded in the publicsuffix package.
+	List *publicsuffix.List
+
+	// KeepLabels is the number of host labels kept in front of the registrable domain,
+	// e.g. a.b.cdn.example.co.uk is requested as cdn.example.co.uk when it is 1. Default: 0.
+	KeepLabels int
+
+	// FullHost are the domains whose hosts are requested in full, e.g. hosting platforms where
+	// subdomains belong to different owners. A domain matches itself and its subdomains.
+	FullHost []string
+}
+
+// Reduce returns the name requested for the host name. IP addresses, public suffixes
+// and invalid names are returned as is.
+func (r *DomainReduction) Reduce(host string) string {
+	if net.ParseIP(host) != nil {
+		return host
+	}
+
+	name := strings.TrimSuffix(strings.ToLower(host), ".")
+
+	for _, domain := range r.FullHost {
+		domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+		if name == domain || strings.HasSuffix(name, "."+domain) {
+			return host
+		}
+	}
+
+	list := r.List
+	if list == nil {
+		list = publicsuffix.Default()
+	}
+
+	registrable, err := list.RegistrableDomain(name)
+	if err != nil {
+		return host
+	}
+
+	// The list returns the ASCII form of internationalized names, so the labels are taken from the name itself
+	// to keep its form.
+	labels := strings.Split(name, ".")
+
+	keep := strings.Count(registrable, ".") + 1
+	if r.KeepLabels > 0 {
+		keep += r.KeepLabels
+	}
+
+	if keep < len(labels) {
+		labels = labels[len(labels)-keep:]
+	}
+
+	return strings.Join(labels, ".")
+}
+
+// WithDomainReduction returns the Decorator that requests the names reduced according to the policy
+// instead of the host names passed to Get and GetRaw. The response describes the requested name.
+func WithDomainReduction(reduction DomainReduction) Decorator {
+	return func(service WCategorizationService) WCategorizationService {
+		return &reducingService{WCategorizationService: service, reduction: reduction}
+	}
+}
+
+// reducingService is the WCategorizationService that requests reduced domain names.
+type reducingService struct {
+	WCategorizationService
+
+	reduction DomainReduction
+}
+
+// Get returns parsed Website Categorization API response for the reduced domain name.
+func (s *reducingService) Get(ctx context.Context, domainName string, opts ...Option) (
+	*WCategorizationResponse, *Response, error) {
+	return s.WCategorizationService.Get(ctx, s.reduction.Reduce(domainName), opts...)
+}
+
+// GetRaw returns raw Website Categorization API response for the reduced domain name.
+func (s *reducingService) GetRaw(ctx context.Context, domainName string, opts ...Option) (*Response, error) {
+	return s.WCategorizationService.GetRaw(ctx, s.reduction.Reduce(domainName), opts...)
+}
@@ -0,0 +1,112 @@
+package websitecategorization
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/whois-api-llc/website-categorization-go/publicsuffix"
+)
+
+// TestDomainReductionReduce tests the names requested for host names.
+func TestDomainReductionReduce(t *testing.T) {
+	tests := []struct {
+		name      string
+		reduction DomainReduction
+		host      string
+		want      string
+	}{
+		{
+			name: "registrable domain",
+			host: "a.b.cdn.example.co.uk",
+			want: "example.co.uk",
+		},
+		{
+			name: "already registrable",
+			host: "Example.com.",
+			want: "example.com",
+		},
+		{
+			name:      "keep labels",
+			reduction: DomainReduction{KeepLabels: 1},
+			host:      "a.b.cdn.example.co.uk",
+			want:      "cdn.example.co.uk",
+		},
+		{
+			name:      "keep more labels than the host has",
+			reduction: DomainReduction{KeepLabels: 3},
+			host:      "cdn.example.co.uk",
+			want:      "cdn.example.co.uk",
+		},
+		{
+			name:      "full host",
+			reduction: DomainReduction{FullHost: []string{"example.com"}},
+			host:      "shop.Example.com",
+			want:      "shop.Example.com",
+		},
+		{
+			name:      "full host does not match other domains",
+			reduction: DomainReduction{FullHost: []string{"example.com"}},
+			host:      "shop.myexample.com",
+			want:      "myexample.com",
+		},
+		{
+			name: "private suffix",
+			host: "www.project.github.io",
+			want: "project.github.io",
+		},
+		{
+			name: "internationalized",
+			host: "www.食狮.公司.cn",
+			want: "食狮.公司.cn",
+		},
+		{
+			name: "public suffix",
+			host: "co.uk",
+			want: "co.uk",
+		},
+		{
+			name: "IP address",
+			host: "192.0.2.1",
+			want: "192.0.2.1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.reduction.Reduce(tt.host); got != tt.want {
+				t.Errorf("Reduce() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestWithDomainReduction tests that subdomains of the same website share the cache entry.
+func TestWithDomainReduction(t *testing.T) {
+	list, err := publicsuffix.Parse(strings.NewReader("com\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fake := &fakeService{}
+	service := Decorate(fake,
+		WithDomainReduction(DomainReduction{List: list}),
+		WithCache(NewMemoryCache(0, 0), 0),
+	)
+
+	ctx := context.Background()
+
+	for _, host := range []string{"a.example.com", "b.example.com", "example.com"} {
+		resp, _, err := service.Get(ctx, host)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.DomainName != "example.com" {
+			t.Errorf("Get(%q) domain name = %q, want example.com", host, resp.DomainName)
+		}
+	}
+
+	if len(fake.calls) != 1 {
+		t.Errorf("calls = %v, want a single request", fake.calls)
+	}
+}
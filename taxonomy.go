@@ -0,0 +1,236 @@
+package websitecategorization
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// TaxonomyTarget is a category of an external taxonomy an API category maps to.
+type TaxonomyTarget struct {
+	// ID is the category identifier in the external taxonomy, e.g. "IAB9-30" or "596".
+	ID string `json:"id"`
+
+	// Name is the readable name of the external category.
+	Name string `json:"name,omitempty"`
+
+	// Weight scales the confidence of the API category, e.g. 0.5 for partial matches.
+	// It must be between 0 and 1, zero is replaced with 1.
+	Weight float64 `json:"weight,omitempty"`
+}
+
+// TaxonomyMapping maps an API category to one or more external categories.
+type TaxonomyMapping struct {
+	// CategoryID is the API category ID.
+	CategoryID int `json:"categoryId"`
+
+	// Targets are the external categories.
+	Targets []TaxonomyTarget `json:"targets"`
+}
+
+// TaxonomyTable is the mapping table of an external taxonomy as stored in files loaded by LoadTaxonomy.
+type TaxonomyTable struct {
+	// Name is the taxonomy name, e.g. "IAB Content Taxonomy 2.2 Tier 1".
+	Name string `json:"name"`
+
+	// Mappings are the mappings of the API categories.
+	Mappings []TaxonomyMapping `json:"mappings"`
+}
+
+// Taxonomy translates API categories into an external taxonomy.
+type Taxonomy struct {
+	name     string
+	mappings map[int][]TaxonomyTarget
+}
+
+// NewTaxonomy creates Taxonomy from the mapping table.
+func NewTaxonomy(table TaxonomyTable) (*Taxonomy, error) {
+	t := &Taxonomy{
+		name:     table.Name,
+		mappings: make(map[int][]TaxonomyTarget, len(table.Mappings)),
+	}
+
+	for i, mapping := range table.Mappings {
+		if _, ok := t.mappings[mapping.CategoryID]; ok {
+			return nil, &ArgError{fmt.Sprintf("mappings[%d].categoryId", i),
+				fmt.Sprintf("is duplicated: %d", mapping.CategoryID)}
+		}
+
+		targets := make([]TaxonomyTarget, 0, len(mapping.Targets))
+
+		for j, target := range mapping.Targets {
+			target.ID = strings.TrimSpace(target.ID)
+			if target.ID == "" {
+				return nil, &ArgError{fmt.Sprintf("mappings[%d].targets[%d].id", i, j), "must not be empty"}
+			}
+
+			if target.Weight < 0 || target.Weight > 1 {
+				return nil, &ArgError{fmt.Sprintf("mappings[%d].targets[%d].weight", i, j), "must be between 0 and 1"}
+			}
+
+			if target.Weight == 0 {
+				target.Weight = 1
+			}
+
+			targets = append(targets, target)
+		}
+
+		t.mappings[mapping.CategoryID] = targets
+	}
+
+	return t, nil
+}
+
+// LoadTaxonomy creates Taxonomy from the JSON file with TaxonomyTable.
+func LoadTaxonomy(path string) (*Taxonomy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var table TaxonomyTable
+	if err = json.Unmarshal(data, &table); err != nil {
+		return nil, fmt.Errorf("cannot parse taxonomy: %w", err)
+	}
+
+	t, err := NewTaxonomy(table)
+	if err != nil {
+		return nil, fmt.Errorf("invalid taxonomy: %w", err)
+	}
+
+	return t, nil
+}
+
+// Name returns the taxonomy name.
+func (t *Taxonomy) Name() string {
+	return t.name
+}
+
+// TaxonomyCategory is a category of an external taxonomy assigned to a website.
+type TaxonomyCategory struct {
+	// ID is the category identifier in the external taxonomy.
+	ID string `json:"id"`
+
+	// Name is the readable name of the external category.
+	Name string `json:"name,omitempty"`
+
+	// Confidence is the highest confidence of the API categories mapped to the category multiplied by the weight
+	// of their mapping.
+	Confidence float64 `json:"confidence"`
+
+	// Sources are the IDs of the API categories mapped to the category.
+	Sources []int `json:"sources"`
+}
+
+// TaxonomyResult is the categorization result translated into an external taxonomy.
+type TaxonomyResult struct {
+	// Taxonomy is the taxonomy name.
+	Taxonomy string `json:"taxonomy"`
+
+	// Categories are the external categories sorted by confidence.
+	Categories []TaxonomyCategory `json:"categories"`
+
+	// Unmapped are the API categories that have no mapping in the taxonomy.
+	Unmapped []Category `json:"unmapped,omitempty"`
+}
+
+// Map translates the categories of the response. A mapping without targets deliberately maps the API
+// category to nothing, such categories are not reported as unmapped.
+func (t *Taxonomy) Map(resp *WCategorizationResponse) TaxonomyResult {
+	result := TaxonomyResult{
+		Taxonomy:   t.name,
+		Categories: []TaxonomyCategory{},
+	}
+
+	if resp == nil {
+		return result
+	}
+
+	index := make(map[string]int)
+
+	for _, category := range resp.Categories {
+		targets, ok := t.mappings[category.ID]
+		if !ok {
+			result.Unmapped = append(result.Unmapped, category)
+
+			continue
+		}
+
+		for _, target := range targets {
+			confidence := category.Confidence * target.Weight
+
+			i, ok := index[target.ID]
+			if !ok {
+				index[target.ID] = len(result.Categories)
+				result.Categories = append(result.Categories, TaxonomyCategory{
+					ID:         target.ID,
+					Name:       target.Name,
+					Confidence: confidence,
+					Sources:    []int{category.ID},
+				})
+
+				continue
+			}
+
+			mapped := &result.Categories[i]
+			mapped.Sources = appendID(mapped.Sources, category.ID)
+
+			if confidence > mapped.Confidence {
+				mapped.Confidence = confidence
+			}
+		}
+	}
+
+	sort.SliceStable(result.Categories, func(i, j int) bool {
+		if result.Categories[i].Confidence != result.Categories[j].Confidence {
+			return result.Categories[i].Confidence > result.Categories[j].Confidence
+		}
+
+		return result.Categories[i].ID < result.Categories[j].ID
+	})
+
+	return result
+}
+
+// TaxonomyValidation is the result of checking the mapping table against the category directory.
+type TaxonomyValidation struct {
+	// Unknown are the mapped category IDs missing from the directory, e.g. removed categories.
+	Unknown []int
+
+	// Unmapped are the directory categories that have no mapping.
+	Unmapped []CategoryItem
+}
+
+// Valid reports whether every mapped category is in the directory.
+func (v TaxonomyValidation) Valid() bool {
+	return len(v.Unknown) == 0
+}
+
+// Validate checks the mapping table against the category directory returned by GetAllCategories.
+// Both lists of the result are sorted by ID.
+func (t *Taxonomy) Validate(categories []CategoryItem) TaxonomyValidation {
+	var v TaxonomyValidation
+
+	known := make(map[int]bool, len(categories))
+
+	for _, category := range categories {
+		known[category.ID] = true
+
+		if _, ok := t.mappings[category.ID]; !ok {
+			v.Unmapped = append(v.Unmapped, category)
+		}
+	}
+
+	for id := range t.mappings {
+		if !known[id] {
+			v.Unknown = append(v.Unknown, id)
+		}
+	}
+
+	sort.Ints(v.Unknown)
+	sort.Slice(v.Unmapped, func(i, j int) bool { return v.Unmapped[i].ID < v.Unmapped[j].ID })
+
+	return v
+}
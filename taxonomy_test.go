@@ -0,0 +1,134 @@
+package websitecategorization
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// testTaxonomy is the sample mapping table.
+var testTaxonomy = TaxonomyTable{
+	Name: "test",
+	Mappings: []TaxonomyMapping{
+		{CategoryID: 1, Targets: []TaxonomyTarget{{ID: "gambling", Name: "Gambling"}}},
+		{CategoryID: 3, Targets: []TaxonomyTarget{{ID: "shopping"}, {ID: "finance", Weight: 0.5}}},
+		{CategoryID: 5, Targets: []TaxonomyTarget{{ID: "finance"}}},
+		{CategoryID: 4},
+		{CategoryID: 9, Targets: []TaxonomyTarget{{ID: "removed"}}},
+	},
+}
+
+// TestTaxonomyMap tests the translation of categories into the external taxonomy.
+func TestTaxonomyMap(t *testing.T) {
+	taxonomy, err := NewTaxonomy(testTaxonomy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		categories   []Category
+		want         []TaxonomyCategory
+		wantUnmapped []Category
+	}{
+		{
+			name:       "one-to-one",
+			categories: []Category{{ID: 1, Confidence: 0.8}},
+			want:       []TaxonomyCategory{{ID: "gambling", Name: "Gambling", Confidence: 0.8, Sources: []int{1}}},
+		},
+		{
+			name:       "one-to-many with weights",
+			categories: []Category{{ID: 3, Confidence: 0.8}},
+			want: []TaxonomyCategory{
+				{ID: "shopping", Confidence: 0.8, Sources: []int{3}},
+				{ID: "finance", Confidence: 0.4, Sources: []int{3}},
+			},
+		},
+		{
+			name:       "many-to-one takes the highest confidence",
+			categories: []Category{{ID: 3, Confidence: 0.9}, {ID: 5, Confidence: 0.6}},
+			want: []TaxonomyCategory{
+				{ID: "shopping", Confidence: 0.9, Sources: []int{3}},
+				{ID: "finance", Confidence: 0.6, Sources: []int{3, 5}},
+			},
+		},
+		{
+			name:         "unmapped and ignored categories",
+			categories:   []Category{{ID: 2, Confidence: 0.7}, {ID: 4, Confidence: 0.9}},
+			want:         []TaxonomyCategory{},
+			wantUnmapped: []Category{{ID: 2, Confidence: 0.7}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := taxonomy.Map(&WCategorizationResponse{Categories: tt.categories})
+
+			if got.Taxonomy != "test" {
+				t.Errorf("Map() taxonomy = %q, want %q", got.Taxonomy, "test")
+			}
+
+			if !reflect.DeepEqual(got.Categories, tt.want) {
+				t.Errorf("Map() categories = %v, want %v", got.Categories, tt.want)
+			}
+
+			if !reflect.DeepEqual(got.Unmapped, tt.wantUnmapped) {
+				t.Errorf("Map() unmapped = %v, want %v", got.Unmapped, tt.wantUnmapped)
+			}
+		})
+	}
+}
+
+// TestNewTaxonomyInvalid tests the validation of mapping tables.
+func TestNewTaxonomyInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		mappings []TaxonomyMapping
+	}{
+		{
+			name:     "duplicated category",
+			mappings: []TaxonomyMapping{{CategoryID: 1}, {CategoryID: 1}},
+		},
+		{
+			name:     "empty target",
+			mappings: []TaxonomyMapping{{CategoryID: 1, Targets: []TaxonomyTarget{{ID: " "}}}},
+		},
+		{
+			name:     "invalid weight",
+			mappings: []TaxonomyMapping{{CategoryID: 1, Targets: []TaxonomyTarget{{ID: "a", Weight: 1.5}}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var argErr *ArgError
+			if _, err := NewTaxonomy(TaxonomyTable{Mappings: tt.mappings}); !errors.As(err, &argErr) {
+				t.Errorf("NewTaxonomy() error = %v, want ArgError", err)
+			}
+		})
+	}
+}
+
+// TestTaxonomyValidate tests checking the mapping table against the category directory.
+func TestTaxonomyValidate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "taxonomy.json")
+
+	data := `{"name": "test", "mappings": [{"categoryId": 1, "targets": [{"id": "a"}]}, {"categoryId": 9, "targets": []}]}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	taxonomy, err := LoadTaxonomy(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := taxonomy.Validate(testDirectory)
+	if got.Valid() || !reflect.DeepEqual(got.Unknown, []int{9}) {
+		t.Errorf("Validate() unknown = %v, want [9]", got.Unknown)
+	}
+
+	if len(got.Unmapped) != len(testDirectory)-1 || got.Unmapped[0].ID != 2 {
+		t.Errorf("Validate() unmapped = %v", got.Unmapped)
+	}
+}
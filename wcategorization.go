@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 )
 
 // WCategorizationService is an interface for Website Categorization API.
@@ -34,23 +36,57 @@ type Response struct {
 
 // wCategorizationServiceOp is the type implementing the WCategorization interface.
 type wCategorizationServiceOp struct {
-	client  *Client
-	baseURL *url.URL
+	client *Client
+
+	// domainURL and categoriesURL are the endpoint URLs. They are never modified after the service
+	// is created, so the service is safe for concurrent use.
+	domainURL     *url.URL
+	categoriesURL *url.URL
 }
 
 var _ WCategorizationService = &wCategorizationServiceOp{}
 
-// newRequest creates the API request with default parameters and the specified apiKey.
-func (service wCategorizationServiceOp) newRequest() (*http.Request, error) {
-	req, err := service.client.NewRequest(http.MethodGet, service.baseURL, nil)
+// newWCategorizationServiceOp creates the service with the endpoint URLs resolved against the base URL.
+// The base URL is copied, so later changes of it do not affect the service.
+func newWCategorizationServiceOp(client *Client, baseURL *url.URL) *wCategorizationServiceOp {
+	categoriesURL := *baseURL
+	categoriesURL.Path = strings.TrimRight(baseURL.Path, "/") + "/categories"
+
+	if baseURL.RawPath != "" {
+		categoriesURL.RawPath = strings.TrimRight(baseURL.RawPath, "/") + "/categories"
+	}
+
+	domainURL := *baseURL
+
+	return &wCategorizationServiceOp{
+		client:        client,
+		domainURL:     &domainURL,
+		categoriesURL: &categoriesURL,
+	}
+}
+
+// endpointURL returns a new copy of the endpoint URL.
+func (service wCategorizationServiceOp) endpointURL(endpoint Endpoint) *url.URL {
+	var u url.URL
+
+	if endpoint == EndpointCategories {
+		u = *service.categoriesURL
+	} else {
+		u = *service.domainURL
+	}
+
+	return &u
+}
+
+// newRequest creates the API request for the endpoint with default parameters.
+// The API key is set by request.
+func (service wCategorizationServiceOp) newRequest(endpoint Endpoint) (*http.Request, error) {
+	req, err := service.client.NewRequest(http.MethodGet, service.endpointURL(endpoint), nil)
 	if err != nil {
 		return nil, err
 	}
 
-	query := url.Values{}
-	query.Set("apiKey", service.client.apiKey)
-
-	req.URL.RawQuery = query.Encode()
+	req.URL.RawQuery = url.Values{}.Encode()
 
 	return req, nil
 }
@@ -61,17 +97,45 @@ type apiResponse struct {
 	ErrorMessage
 }
 
+// checkOptions returns the options applicable to the endpoint. Inapplicable options are rejected with ArgError
+// if the client uses strict options, otherwise they are dropped and reported to the client option warning function.
+func (service wCategorizationServiceOp) checkOptions(endpoint Endpoint, opts []Option) ([]Option, error) {
+	applicable := make([]Option, 0, len(opts))
+
+	for _, opt := range opts {
+		if opt.AppliesTo(endpoint) {
+			applicable = append(applicable, opt)
+
+			continue
+		}
+
+		err := &ArgError{opt.Name(), "is not applicable to the " + endpoint.String() + " endpoint"}
+
+		if service.client.strictOptions {
+			return nil, err
+		}
+
+		if service.client.optionWarning != nil {
+			service.client.optionWarning(err)
+		}
+	}
+
+	return applicable, nil
+}
+
 // requestCategories returns intermediate API response for the /categories path.
 func (service wCategorizationServiceOp) requestCategories(ctx context.Context, opts ...Option) (*Response, error) {
-	categoriesURL := service.baseURL
-	categoriesURL.Path += "/categories"
+	opts, err := service.checkOptions(EndpointCategories, opts)
+	if err != nil {
+		return nil, err
+	}
 
-	req, err := service.client.NewRequest(http.MethodGet, categoriesURL, nil)
+	req, err := service.newRequest(EndpointCategories)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := service.request(ctx, req, opts...)
+	resp, err := service.request(ctx, req, false, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -85,7 +149,12 @@ func (service wCategorizationServiceOp) requestBase(ctx context.Context, domainN
 		return nil, &ArgError{"domainName", "can not be empty"}
 	}
 
-	req, err := service.newRequest()
+	opts, err := service.checkOptions(EndpointDomain, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := service.newRequest(EndpointDomain)
 	if err != nil {
 		return nil, err
 	}
@@ -94,7 +163,7 @@ func (service wCategorizationServiceOp) requestBase(ctx context.Context, domainN
 	q.Set("domainName", domainName)
 	req.URL.RawQuery = q.Encode()
 
-	resp, err := service.request(ctx, req, opts...)
+	resp, err := service.request(ctx, req, true, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -103,27 +172,77 @@ func (service wCategorizationServiceOp) requestBase(ctx context.Context, domainN
 }
 
 // request returns intermediate API response for further actions.
-func (service wCategorizationServiceOp) request(ctx context.Context, req *http.Request, opts ...Option) (*Response, error) {
+// Billable requests are counted by the client budget. The request is repeated with another API key
+// if the API rejects the key.
+func (service wCategorizationServiceOp) request(
+	ctx context.Context,
+	req *http.Request,
+	billable bool,
+	opts ...Option,
+) (*Response, error) {
 	q := req.URL.Query()
-	for _, opt := range opts {
-		opt(q)
+	applyOptions(q, opts)
+
+	if err := validateQuery(q); err != nil {
+		return nil, err
 	}
-	req.URL.RawQuery = q.Encode()
 
-	var b bytes.Buffer
+	keys := service.client.keys
+	budget := service.client.budget
+	tried := make(map[int]bool)
+
+	var budgetErr *BudgetExceededError
+
+	for {
+		keyIndex, apiKey, err := keys.acquire(tried)
+		if err != nil {
+			if budgetErr != nil {
+				return nil, budgetErr
+			}
+
+			return nil, err
+		}
+
+		tried[keyIndex] = true
+
+		if billable && budget != nil {
+			if err = budget.reserve(ctx, apiKey); err != nil {
+				if errors.As(err, &budgetErr) && budgetErr.Scope == BudgetScopeKeyDaily {
+					// Another key may still have the budget.
+					continue
+				}
+
+				return nil, err
+			}
+		}
+
+		q.Set("apiKey", apiKey)
+		req.URL.RawQuery = q.Encode()
+
+		var b bytes.Buffer
+
+		resp, err := service.client.Do(ctx, req, &b)
+		redactResponse(resp)
+
+		if err != nil && resp == nil && billable && budget != nil {
+			// The request has not reached the API.
+			budget.release(ctx, apiKey)
+		}
+
+		if err == nil && keys.report(keyIndex, resp) {
+			if billable && budget != nil {
+				// The key was rejected, so the API has not billed the request.
+				budget.release(ctx, apiKey)
+			}
+
+			continue
+		}
 
-	resp, err := service.client.Do(ctx, req, &b)
-	if err != nil {
 		return &Response{
 			Response: resp,
 			Body:     b.Bytes(),
 		}, err
 	}
-
-	return &Response{
-		Response: resp,
-		Body:     b.Bytes(),
-	}, nil
 }
 
 // parse parses raw Website Categorization API response.
@@ -158,7 +277,7 @@ func (service wCategorizationServiceOp) Get(
 ) (wCategorizationResponse *WCategorizationResponse, resp *Response, err error) {
 	optsJSON := make([]Option, 0, len(opts)+1)
 	optsJSON = append(optsJSON, opts...)
-	optsJSON = append(optsJSON, OptionOutputFormat("JSON"))
+	optsJSON = append(optsJSON, OptionOutputFormat(OutputFormatJSON))
 
 	resp, err = service.requestBase(ctx, domainName, optsJSON...)
 	if err != nil {
@@ -203,7 +322,7 @@ func (service wCategorizationServiceOp) GetAllCategories(ctx context.Context, op
 	categories []CategoryItem, resp *Response, err error) {
 	optsJSON := make([]Option, 0, len(opts)+1)
 	optsJSON = append(optsJSON, opts...)
-	optsJSON = append(optsJSON, OptionOutputFormat("JSON"))
+	optsJSON = append(optsJSON, OptionOutputFormat(OutputFormatJSON))
 
 	resp, err = service.requestCategories(ctx, optsJSON...)
 	if err != nil {
@@ -0,0 +1,411 @@
+package websitecategorization
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventType is the type of WebhookEvent.
+type EventType string
+
+const (
+	// EventCategoryChange is sent for ChangeEvent reported by Monitor.
+	EventCategoryChange EventType = "category.change"
+
+	// EventPolicyViolation is sent for websites blocked by Policy.
+	EventPolicyViolation EventType = "policy.violation"
+
+	// EventBudgetThreshold is sent for BudgetThreshold reported by Budget.
+	EventBudgetThreshold EventType = "budget.threshold"
+)
+
+// Webhook headers.
+const (
+	// WebhookEventHeader is the header with the event type.
+	WebhookEventHeader = "X-Wcategorization-Event"
+
+	// WebhookTimestampHeader is the header with the Unix time of the delivery.
+	WebhookTimestampHeader = "X-Wcategorization-Timestamp"
+
+	// WebhookSignatureHeader is the header with the signature of the delivery, see SignWebhook.
+	WebhookSignatureHeader = "X-Wcategorization-Signature"
+)
+
+// WebhookEvent is the JSON body posted to webhooks.
+type WebhookEvent struct {
+	// ID is the unique event identifier. Receivers can use it to drop duplicated deliveries.
+	ID string `json:"id"`
+
+	// Type is the event type.
+	Type EventType `json:"type"`
+
+	// Time is the time of the event.
+	Time time.Time `json:"time"`
+
+	// Data is ChangeEvent, PolicyViolation or BudgetThreshold according to the type.
+	Data interface{} `json:"data"`
+}
+
+// PolicyViolation describes the website blocked by Policy.
+type PolicyViolation struct {
+	// DomainName is the blocked domain name.
+	DomainName string `json:"domainName"`
+
+	// Matched are the categories that caused the block, empty for uncategorized websites.
+	Matched []Category `json:"matched"`
+
+	// Source is the origin of the categorization result if it is not the API.
+	Source ResultSource `json:"source,omitempty"`
+}
+
+// webhookEventSeq numbers the events whose IDs could not be generated randomly.
+var webhookEventSeq uint64
+
+// NewWebhookEvent creates WebhookEvent with a random ID and the current time. If the system random
+// generator fails, the ID is made of the current time and a sequence number, so it is still unique
+// within the process.
+func NewWebhookEvent(eventType EventType, data interface{}) WebhookEvent {
+	now := time.Now().UTC()
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		binary.BigEndian.PutUint64(id, uint64(now.UnixNano()))
+		binary.BigEndian.PutUint64(id[8:], atomic.AddUint64(&webhookEventSeq, 1))
+	}
+
+	return WebhookEvent{
+		ID:   hex.EncodeToString(id),
+		Type: eventType,
+		Time: now,
+		Data: data,
+	}
+}
+
+// CategoryChangeEvent returns WebhookEvent of the change found by Monitor.
+func CategoryChangeEvent(change ChangeEvent) WebhookEvent {
+	event := NewWebhookEvent(EventCategoryChange, change)
+	event.Time = change.Time.UTC()
+
+	return event
+}
+
+// PolicyViolationEvent returns WebhookEvent of the website blocked by the decision.
+func PolicyViolationEvent(resp *WCategorizationResponse, decision Decision) WebhookEvent {
+	violation := PolicyViolation{Matched: decision.Matched}
+	if violation.Matched == nil {
+		violation.Matched = []Category{}
+	}
+
+	if resp != nil {
+		violation.DomainName = resp.DomainName
+		violation.Source = resp.Source
+	}
+
+	return NewWebhookEvent(EventPolicyViolation, violation)
+}
+
+// BudgetThresholdEvent returns WebhookEvent of the reached budget threshold.
+func BudgetThresholdEvent(threshold BudgetThreshold) WebhookEvent {
+	return NewWebhookEvent(EventBudgetThreshold, threshold)
+}
+
+// Webhook is the URL events are posted to.
+type Webhook struct {
+	// URL is the webhook URL.
+	URL string `json:"url"`
+
+	// Secret is the key of the HMAC signature. The signature header is not sent if it is empty.
+	Secret string `json:"secret,omitempty"`
+
+	// Events are the event types sent to the webhook. Default: all types.
+	Events []EventType `json:"events,omitempty"`
+}
+
+// accepts reports whether the webhook receives events of the type.
+func (w *Webhook) accepts(eventType EventType) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+
+	for _, t := range w.Events {
+		if t == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SignWebhook returns the value of WebhookSignatureHeader: "sha256=" followed by the hex encoded HMAC-SHA256
+// of the timestamp, a dot and the body.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhook reports whether the request body is signed with the secret and the timestamp is not older
+// than maxAge. Zero maxAge disables the timestamp check.
+func VerifyWebhook(secret string, header http.Header, body []byte, maxAge time.Duration) bool {
+	timestamp := header.Get(WebhookTimestampHeader)
+
+	if maxAge > 0 {
+		unix, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil || time.Since(time.Unix(unix, 0)) > maxAge {
+			return false
+		}
+	}
+
+	return hmac.Equal([]byte(header.Get(WebhookSignatureHeader)), []byte(SignWebhook(secret, timestamp, body)))
+}
+
+// WebhookError is returned when an event could not be delivered to a webhook.
+type WebhookError struct {
+	// URL is the webhook URL.
+	URL string
+
+	// StatusCode is the status code of the last response, zero if there is no response.
+	StatusCode int
+
+	// Err is the error of the last attempt if there is no response.
+	Err error
+}
+
+// Error returns error message as a string.
+func (e *WebhookError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("webhook %s: %v", e.URL, e.Err)
+	}
+
+	return fmt.Sprintf("webhook %s: status code %d", e.URL, e.StatusCode)
+}
+
+// Unwrap returns the error of the last attempt.
+func (e *WebhookError) Unwrap() error {
+	return e.Err
+}
+
+// NotifyError is returned by WebhookNotifier.Notify when the event is not delivered to some webhooks.
+// errors.As and errors.Is match any of the errors, e.g. *WebhookError.
+type NotifyError struct {
+	// Errors are the *WebhookError values of the undelivered events followed by the dead-letter write error
+	// of each of them, if any.
+	Errors []error
+}
+
+// Error returns error message as a string.
+func (e *NotifyError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Is reports whether any of the errors matches the target.
+func (e *NotifyError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// As finds the first error that matches the target and sets the target to it.
+func (e *NotifyError) As(target interface{}) bool {
+	for _, err := range e.Errors {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// deadLetter is the line of the dead-letter file.
+type deadLetter struct {
+	Time  time.Time    `json:"time"`
+	URL   string       `json:"url"`
+	Error string       `json:"error"`
+	Event WebhookEvent `json:"event"`
+}
+
+// WebhookNotifier posts events to webhooks. Deliveries failed with network errors, rate limiting
+// or server errors are retried, events that could not be delivered are appended to the dead-letter file.
+// It is safe for concurrent use.
+type WebhookNotifier struct {
+	// Webhooks are the receivers of the events.
+	Webhooks []Webhook
+
+	// HTTPClient is used to post the events. Default: the client with 10 seconds timeout.
+	HTTPClient *http.Client
+
+	// Retry describes how failed deliveries are repeated. Default: 3 attempts, see RetryPolicy.
+	Retry RetryPolicy
+
+	// DeadLetterPath is the JSON Lines file with undelivered events. Undelivered events are dropped if it is empty.
+	DeadLetterPath string
+
+	mu sync.Mutex
+}
+
+// defaultWebhookClient is the HTTP client used when WebhookNotifier.HTTPClient is not set.
+var defaultWebhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// Notify posts the event to every webhook accepting its type. It returns *NotifyError with the errors
+// of undelivered events after they are written to the dead-letter file.
+func (n *WebhookNotifier) Notify(ctx context.Context, event WebhookEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	var failures []error
+
+	for i := range n.Webhooks {
+		webhook := &n.Webhooks[i]
+		if !webhook.accepts(event.Type) {
+			continue
+		}
+
+		err = n.deliver(ctx, webhook, event.Type, body)
+		if err == nil {
+			continue
+		}
+
+		failures = append(failures, err)
+
+		if dlErr := n.deadLetter(webhook.URL, event, err); dlErr != nil {
+			failures = append(failures, dlErr)
+		}
+	}
+
+	if len(failures) > 0 {
+		return &NotifyError{Errors: failures}
+	}
+
+	return nil
+}
+
+// deliver posts the body to the webhook repeating transient failures.
+func (n *WebhookNotifier) deliver(ctx context.Context, webhook *Webhook, eventType EventType, body []byte) error {
+	attempts := n.Retry.Attempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := n.post(ctx, webhook, eventType, body)
+		if err == nil {
+			return nil
+		}
+
+		var webhookErr *WebhookError
+		if errors.As(err, &webhookErr) && webhookErr.Err == nil && webhookErr.StatusCode != http.StatusTooManyRequests &&
+			webhookErr.StatusCode < http.StatusInternalServerError {
+			return err
+		}
+
+		if attempt >= attempts || ctx.Err() != nil {
+			return err
+		}
+
+		timer := time.NewTimer(n.Retry.delay(attempt))
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+
+			return err
+		}
+	}
+}
+
+// post makes one delivery attempt.
+func (n *WebhookNotifier) post(ctx context.Context, webhook *Webhook, eventType EventType, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return &WebhookError{URL: webhook.URL, Err: err}
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(WebhookEventHeader, string(eventType))
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+
+	if webhook.Secret != "" {
+		req.Header.Set(WebhookSignatureHeader, SignWebhook(webhook.Secret, timestamp, body))
+	}
+
+	client := n.HTTPClient
+	if client == nil {
+		client = defaultWebhookClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return &WebhookError{URL: webhook.URL, Err: err}
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &WebhookError{URL: webhook.URL, StatusCode: resp.StatusCode}
+	}
+
+	return nil
+}
+
+// deadLetter appends the undelivered event to the dead-letter file.
+func (n *WebhookNotifier) deadLetter(url string, event WebhookEvent, deliveryErr error) error {
+	if n.DeadLetterPath == "" {
+		return nil
+	}
+
+	line, err := json.Marshal(deadLetter{Time: time.Now().UTC(), URL: url, Error: deliveryErr.Error(), Event: event})
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	file, err := os.OpenFile(n.DeadLetterPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("cannot write dead letter: %w", err)
+	}
+
+	if _, err = file.Write(append(line, '\n')); err != nil {
+		_ = file.Close()
+
+		return fmt.Errorf("cannot write dead letter: %w", err)
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,195 @@
+package websitecategorization
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookReceiver is the test webhook that fails the first deliveries with the status code.
+type webhookReceiver struct {
+	mu       sync.Mutex
+	failures int
+	status   int
+	attempts int
+	events   []WebhookEvent
+	valid    []bool
+}
+
+// ServeHTTP records the event.
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.attempts++
+
+	if r.attempts <= r.failures {
+		w.WriteHeader(r.status)
+
+		return
+	}
+
+	var event WebhookEvent
+	_ = json.Unmarshal(body, &event)
+
+	r.events = append(r.events, event)
+	r.valid = append(r.valid, req.Header.Get(WebhookEventHeader) == string(event.Type) &&
+		VerifyWebhook("secret", req.Header, body, time.Minute))
+}
+
+// TestWebhookNotifier tests the delivery, retries and dead letters of webhook events.
+func TestWebhookNotifier(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		status       int
+		events       []EventType
+		wantAttempts int
+		wantEvents   int
+		wantErr      bool
+	}{
+		{
+			name:         "delivered",
+			wantAttempts: 1,
+			wantEvents:   1,
+		},
+		{
+			name:         "retried server error",
+			failures:     2,
+			status:       http.StatusBadGateway,
+			wantAttempts: 3,
+			wantEvents:   1,
+		},
+		{
+			name:         "retries are over",
+			failures:     3,
+			status:       http.StatusTooManyRequests,
+			wantAttempts: 3,
+			wantErr:      true,
+		},
+		{
+			name:         "client error is not retried",
+			failures:     1,
+			status:       http.StatusBadRequest,
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:   "event type is not accepted",
+			events: []EventType{EventBudgetThreshold},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receiver := &webhookReceiver{failures: tt.failures, status: tt.status}
+
+			server := httptest.NewServer(receiver)
+			defer server.Close()
+
+			deadLetterPath := filepath.Join(t.TempDir(), "dead.jsonl")
+
+			notifier := &WebhookNotifier{
+				Webhooks:       []Webhook{{URL: server.URL, Secret: "secret", Events: tt.events}},
+				Retry:          RetryPolicy{Backoff: time.Millisecond},
+				DeadLetterPath: deadLetterPath,
+			}
+
+			resp := &WCategorizationResponse{DomainName: "casino.example", Categories: []Category{{ID: 1, Confidence: 0.9}}}
+			event := PolicyViolationEvent(resp, (&Policy{Deny: []int{1}}).Decide(resp))
+
+			err := notifier.Notify(context.Background(), event)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Notify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var webhookErr *WebhookError
+			if tt.wantErr && (!errors.As(err, &webhookErr) || webhookErr.URL != server.URL ||
+				webhookErr.StatusCode != tt.status) {
+				t.Errorf("Notify() error = %v, want WebhookError with status %d", err, tt.status)
+			}
+
+			if receiver.attempts != tt.wantAttempts || len(receiver.events) != tt.wantEvents {
+				t.Fatalf("attempts = %d, events = %d, want %d and %d",
+					receiver.attempts, len(receiver.events), tt.wantAttempts, tt.wantEvents)
+			}
+
+			if tt.wantEvents > 0 {
+				got := receiver.events[0]
+				if !receiver.valid[0] || got.ID != event.ID || got.Type != EventPolicyViolation {
+					t.Errorf("received event = %+v, valid signature %v", got, receiver.valid[0])
+				}
+
+				data, _ := got.Data.(map[string]interface{})
+				if data["domainName"] != "casino.example" {
+					t.Errorf("received data = %v", got.Data)
+				}
+			}
+
+			file, err := os.Open(deadLetterPath)
+			if !tt.wantErr {
+				if err == nil {
+					t.Error("dead-letter file is created without failures")
+					_ = file.Close()
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+
+			var letter deadLetter
+
+			scanner := bufio.NewScanner(file)
+			if !scanner.Scan() || json.Unmarshal(scanner.Bytes(), &letter) != nil || letter.Event.ID != event.ID ||
+				letter.URL != server.URL {
+				t.Errorf("dead letter = %s", scanner.Bytes())
+			}
+		})
+	}
+}
+
+// TestVerifyWebhook tests the signature verification of webhook deliveries.
+func TestVerifyWebhook(t *testing.T) {
+	body := []byte(`{"type":"budget.threshold"}`)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	old := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		signed    string
+		want      bool
+	}{
+		{name: "valid", secret: "secret", timestamp: timestamp, signed: timestamp, want: true},
+		{name: "wrong secret", secret: "other", timestamp: timestamp, signed: timestamp},
+		{name: "replaced timestamp", secret: "secret", timestamp: timestamp, signed: old},
+		{name: "expired", secret: "secret", timestamp: old, signed: old},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			header.Set(WebhookTimestampHeader, tt.timestamp)
+			header.Set(WebhookSignatureHeader, SignWebhook("secret", tt.signed, body))
+
+			if got := VerifyWebhook(tt.secret, header, body, time.Minute); got != tt.want {
+				t.Errorf("VerifyWebhook() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}